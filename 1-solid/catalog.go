@@ -0,0 +1,292 @@
+package solid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BetterFilter checks every product against the specification
+// A Catalog keeps indexes on color and size, so the specifications it understands are answered by looking them up
+// Anything else still works: it falls back to checking products one by one, just like the BetterFilter
+type Catalog struct {
+	products []*Product
+	byColor  map[Color][]int
+	bySize   map[Size][]int
+}
+
+func NewCatalog(products ...Product) *Catalog {
+	c := &Catalog{byColor: map[Color][]int{}, bySize: map[Size][]int{}}
+	for _, p := range products {
+		c.Add(p)
+	}
+
+	return c
+}
+
+// Add stores a copy of the product and returns a pointer to it
+func (c *Catalog) Add(p Product) *Product {
+	position := len(c.products)
+	c.products = append(c.products, &p)
+	c.byColor[p.color] = append(c.byColor[p.color], position)
+	c.bySize[p.size] = append(c.bySize[p.size], position)
+
+	return &p
+}
+
+func (c *Catalog) Len() int {
+	return len(c.products)
+}
+
+// Query returns the products satisfying the specification, in the order they were added
+func (c *Catalog) Query(spec Specification) []*Product {
+	result := []*Product{}
+	for _, position := range c.Explain(spec).execute(c) {
+		result = append(result, c.products[position])
+	}
+
+	return result
+}
+
+// QueryPlan tells how the catalog answers a specification
+type QueryPlan struct {
+	Operation string
+	// Spec is the part of the specification this step takes care of, if any
+	Spec     Specification
+	Children []*QueryPlan
+}
+
+const (
+	PlanColorIndex = "color index"
+	PlanSizeIndex  = "size index"
+	PlanIntersect  = "intersect"
+	PlanUnion      = "union"
+	PlanComplement = "complement"
+	PlanFilter     = "filter"
+	PlanAll        = "all"
+	PlanNone       = "none"
+	PlanScan       = "scan"
+)
+
+func (qp *QueryPlan) String() string {
+	sb := strings.Builder{}
+	qp.write(&sb, 0)
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (qp *QueryPlan) write(sb *strings.Builder, indent int) {
+	sb.WriteString(strings.Repeat("  ", indent))
+	sb.WriteString(qp.Operation)
+	if qp.Spec != nil {
+		sb.WriteString(": ")
+		sb.WriteString(FormatSpecification(qp.Spec))
+	}
+	sb.WriteString("\n")
+
+	for _, child := range qp.Children {
+		child.write(sb, indent+1)
+	}
+}
+
+// Explain shows which plan Query uses for the specification
+func (c *Catalog) Explain(spec Specification) *QueryPlan {
+	if plan := c.plan(spec); plan != nil {
+		return plan
+	}
+
+	return &QueryPlan{Operation: PlanScan, Spec: spec}
+}
+
+// plan returns nil when the indexes can't help with the specification
+func (c *Catalog) plan(spec Specification) *QueryPlan {
+	switch s := spec.(type) {
+	case FluentSpecification:
+		return c.plan(s.Specification)
+	case ColorSpecification:
+		return &QueryPlan{Operation: PlanColorIndex, Spec: s}
+	case SizeSpecification:
+		return &QueryPlan{Operation: PlanSizeIndex, Spec: s}
+	case NotSpecification:
+		if child := c.plan(s.spec); child != nil {
+			return &QueryPlan{Operation: PlanComplement, Children: []*QueryPlan{child}}
+		}
+		return nil
+	case AndSpecification:
+		return c.planAll([]Specification{s.first, s.second})
+	case AllOfSpecification:
+		return c.planAll(s.specs)
+	case OrSpecification:
+		return c.planAny([]Specification{s.first, s.second})
+	case AnyOfSpecification:
+		return c.planAny(s.specs)
+	}
+
+	return nil
+}
+
+// The indexed operands of an AND narrow the products down, the others only check what's left
+func (c *Catalog) planAll(specs []Specification) *QueryPlan {
+	if len(specs) == 0 {
+		return &QueryPlan{Operation: PlanAll}
+	}
+
+	indexed := []*QueryPlan{}
+	residual := []Specification{}
+	for _, s := range specs {
+		if plan := c.plan(s); plan != nil {
+			indexed = append(indexed, plan)
+		} else {
+			residual = append(residual, s)
+		}
+	}
+
+	var plan *QueryPlan
+	switch len(indexed) {
+	case 0:
+		return nil
+	case 1:
+		plan = indexed[0]
+	default:
+		plan = &QueryPlan{Operation: PlanIntersect, Children: indexed}
+	}
+
+	switch len(residual) {
+	case 0:
+		return plan
+	case 1:
+		return &QueryPlan{Operation: PlanFilter, Spec: residual[0], Children: []*QueryPlan{plan}}
+	}
+
+	return &QueryPlan{Operation: PlanFilter, Spec: AllOf(residual...), Children: []*QueryPlan{plan}}
+}
+
+// An OR can only use the indexes when every operand can, a single unindexed one means checking everything
+func (c *Catalog) planAny(specs []Specification) *QueryPlan {
+	if len(specs) == 0 {
+		return &QueryPlan{Operation: PlanNone}
+	}
+
+	children := []*QueryPlan{}
+	for _, s := range specs {
+		plan := c.plan(s)
+		if plan == nil {
+			return nil
+		}
+		children = append(children, plan)
+	}
+	if len(children) == 1 {
+		return children[0]
+	}
+
+	return &QueryPlan{Operation: PlanUnion, Children: children}
+}
+
+// execute returns the positions of the matching products, in ascending order
+func (qp *QueryPlan) execute(c *Catalog) []int {
+	switch qp.Operation {
+	case PlanColorIndex:
+		return c.byColor[qp.Spec.(ColorSpecification).color]
+	case PlanSizeIndex:
+		return c.bySize[qp.Spec.(SizeSpecification).size]
+	case PlanAll:
+		return c.allPositions()
+	case PlanNone:
+		return []int{}
+	case PlanComplement:
+		return complementPositions(qp.Children[0].execute(c), len(c.products))
+	case PlanIntersect:
+		result := qp.Children[0].execute(c)
+		for _, child := range qp.Children[1:] {
+			result = intersectPositions(result, child.execute(c))
+		}
+		return result
+	case PlanUnion:
+		result := []int{}
+		for _, child := range qp.Children {
+			result = unionPositions(result, child.execute(c))
+		}
+		return result
+	case PlanFilter:
+		return c.filterPositions(qp.Children[0].execute(c), qp.Spec)
+	case PlanScan:
+		return c.filterPositions(c.allPositions(), qp.Spec)
+	}
+
+	panic(fmt.Sprintf("unknown query plan operation %q", qp.Operation))
+}
+
+func (c *Catalog) allPositions() []int {
+	result := make([]int, len(c.products))
+	for i := range result {
+		result[i] = i
+	}
+
+	return result
+}
+
+func (c *Catalog) filterPositions(positions []int, spec Specification) []int {
+	result := []int{}
+	for _, position := range positions {
+		if spec.IsSatisfied(c.products[position]) {
+			result = append(result, position)
+		}
+	}
+
+	return result
+}
+
+// The index lists are sorted, so combining them is a merge, like in merge sort
+
+func intersectPositions(a, b []int) []int {
+	result := []int{}
+	for i, k := 0, 0; i < len(a) && k < len(b); {
+		switch {
+		case a[i] < b[k]:
+			i++
+		case a[i] > b[k]:
+			k++
+		default:
+			result = append(result, a[i])
+			i++
+			k++
+		}
+	}
+
+	return result
+}
+
+func unionPositions(a, b []int) []int {
+	result := make([]int, 0, len(a)+len(b))
+	i, k := 0, 0
+	for i < len(a) && k < len(b) {
+		switch {
+		case a[i] < b[k]:
+			result = append(result, a[i])
+			i++
+		case a[i] > b[k]:
+			result = append(result, b[k])
+			k++
+		default:
+			result = append(result, a[i])
+			i++
+			k++
+		}
+	}
+	result = append(result, a[i:]...)
+
+	return append(result, b[k:]...)
+}
+
+func complementPositions(positions []int, total int) []int {
+	result := []int{}
+	next := 0
+	for i := 0; i < total; i++ {
+		if next < len(positions) && positions[next] == i {
+			next++
+			continue
+		}
+		result = append(result, i)
+	}
+
+	return result
+}
@@ -0,0 +1,171 @@
+package solid
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Even with the interfaces split, callers still have to type-assert a device to find out what it does,
+// and the OldFashionedPrinter passes as a Scanner and a Faxxer because it kept the deprecated methods
+// So devices advertise their capabilities, and a DeviceRegistry finds devices by them
+
+type Capability uint
+
+const (
+	CanPrint Capability = 1 << iota
+	CanScan
+	CanFax
+)
+
+var capabilityNames = []struct {
+	capability Capability
+	name       string
+}{{CanPrint, "print"}, {CanScan, "scan"}, {CanFax, "fax"}}
+
+// Has tells whether every capability in other is in c
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+func (c Capability) String() string {
+	names := []string{}
+	for _, cn := range capabilityNames {
+		if c.Has(cn.capability) {
+			names = append(names, cn.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+
+	return strings.Join(names, "|")
+}
+
+// CapabilityAdvertiser is for devices with methods they can't actually use
+type CapabilityAdvertiser interface {
+	Capabilities() Capability
+}
+
+func (ofp OldFashionedPrinter) Capabilities() Capability {
+	return CanPrint
+}
+
+// A MultiFunctionalMachine can only do what its components can
+func (mfm MultiFunctionalMachine) Capabilities() Capability {
+	var c Capability
+	if mfm.printer != nil {
+		c |= CapabilitiesOf(mfm.printer) & CanPrint
+	}
+	if mfm.scanner != nil {
+		c |= CapabilitiesOf(mfm.scanner) & CanScan
+	}
+
+	return c
+}
+
+// CapabilitiesOf works out what a device can do from the interfaces it implements
+// Devices advertising their capabilities can narrow that down, but can't claim more than they implement
+func CapabilitiesOf(device any) Capability {
+	var c Capability
+	if _, ok := device.(Printer); ok {
+		c |= CanPrint
+	}
+	if _, ok := device.(Scanner); ok {
+		c |= CanScan
+	}
+	if _, ok := device.(Faxxer); ok {
+		c |= CanFax
+	}
+
+	if advertiser, ok := device.(CapabilityAdvertiser); ok {
+		c &= advertiser.Capabilities()
+	}
+
+	return c
+}
+
+var (
+	ErrDuplicateDevice = errors.New("device already registered")
+	ErrIncapableDevice = errors.New("device can't do anything")
+)
+
+// DeviceHandle only hands out the interfaces the device is able to use
+type DeviceHandle struct {
+	name         string
+	device       any
+	capabilities Capability
+}
+
+func (dh DeviceHandle) Name() string             { return dh.name }
+func (dh DeviceHandle) Capabilities() Capability { return dh.capabilities }
+
+func (dh DeviceHandle) Printer() (Printer, bool) {
+	if !dh.capabilities.Has(CanPrint) {
+		return nil, false
+	}
+
+	return dh.device.(Printer), true
+}
+
+func (dh DeviceHandle) Scanner() (Scanner, bool) {
+	if !dh.capabilities.Has(CanScan) {
+		return nil, false
+	}
+
+	return dh.device.(Scanner), true
+}
+
+func (dh DeviceHandle) Faxxer() (Faxxer, bool) {
+	if !dh.capabilities.Has(CanFax) {
+		return nil, false
+	}
+
+	return dh.device.(Faxxer), true
+}
+
+type DeviceRegistry struct {
+	mu      sync.RWMutex
+	devices []DeviceHandle
+}
+
+func NewDeviceRegistry() *DeviceRegistry {
+	return &DeviceRegistry{}
+}
+
+func (dr *DeviceRegistry) Register(name string, device any) (DeviceHandle, error) {
+	capabilities := CapabilitiesOf(device)
+	if capabilities == 0 {
+		return DeviceHandle{}, fmt.Errorf("%w: %s", ErrIncapableDevice, name)
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	for _, d := range dr.devices {
+		if d.name == name {
+			return DeviceHandle{}, fmt.Errorf("%w: %s", ErrDuplicateDevice, name)
+		}
+	}
+
+	handle := DeviceHandle{name, device, capabilities}
+	dr.devices = append(dr.devices, handle)
+
+	return handle, nil
+}
+
+// Find returns the devices with every one of the capabilities, in the order they were registered
+func (dr *DeviceRegistry) Find(capabilities Capability) []DeviceHandle {
+	dr.mu.RLock()
+	defer dr.mu.RUnlock()
+
+	result := []DeviceHandle{}
+	for _, d := range dr.devices {
+		if d.capabilities.Has(capabilities) {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
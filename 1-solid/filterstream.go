@@ -0,0 +1,131 @@
+package solid
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// BetterFilter.Filter needs every product in memory at once
+// The specifications don't care where products come from though, so the same filter can work on streams too
+
+// ProductIterator hands out products one at a time, until it returns false
+type ProductIterator interface {
+	Next() (*Product, bool)
+}
+
+type sliceIterator struct {
+	products []Product
+	next     int
+}
+
+// IterateProducts walks a slice, handing out pointers into it
+func IterateProducts(products []Product) ProductIterator {
+	return &sliceIterator{products: products}
+}
+
+func (si *sliceIterator) Next() (*Product, bool) {
+	if si.next >= len(si.products) {
+		return nil, false
+	}
+	si.next++
+
+	return &si.products[si.next-1], true
+}
+
+type filterIterator struct {
+	source ProductIterator
+	spec   Specification
+}
+
+// FilterIterator is lazy: products are only checked as the returned iterator is advanced
+func (bf *BetterFilter) FilterIterator(source ProductIterator, spec Specification) ProductIterator {
+	return &filterIterator{source, spec}
+}
+
+func (fi *filterIterator) Next() (*Product, bool) {
+	for {
+		p, ok := fi.source.Next()
+		if !ok {
+			return nil, false
+		}
+		if fi.spec.IsSatisfied(p) {
+			return p, true
+		}
+	}
+}
+
+// FilterChan passes along the products from in that satisfy the specification
+// The returned channel is closed once in is closed or the context is done
+func (bf *BetterFilter) FilterChan(ctx context.Context, in <-chan *Product, spec Specification) <-chan *Product {
+	out := make(chan *Product)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case p, ok := <-in:
+				if !ok {
+					return
+				}
+				if !spec.IsSatisfied(p) {
+					continue
+				}
+				select {
+				case out <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Below this many products, starting goroutines costs more than it saves
+const minParallelChunk = 1024
+
+// FilterParallel splits the products among workers, and returns the results in the same order as Filter would
+// With workers <= 0 it uses one worker per CPU
+// The specification is called from several goroutines at once, so it must be safe for concurrent use
+func (bf *BetterFilter) FilterParallel(products []Product, spec Specification, workers int) []*Product {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	chunkSize := (len(products) + workers - 1) / workers
+	if chunkSize < minParallelChunk {
+		chunkSize = minParallelChunk
+	}
+	if chunkSize >= len(products) {
+		return bf.Filter(products, spec)
+	}
+
+	// Every chunk keeps its own results, and gluing them in chunk order keeps the input order
+	chunks := make([][]*Product, (len(products)+chunkSize-1)/chunkSize)
+	wg := sync.WaitGroup{}
+	for c := range chunks {
+		start := c * chunkSize
+		end := start + chunkSize
+		if end > len(products) {
+			end = len(products)
+		}
+
+		wg.Add(1)
+		go func(c int, part []Product) {
+			defer wg.Done()
+			chunks[c] = bf.Filter(part, spec)
+		}(c, products[start:end])
+	}
+	wg.Wait()
+
+	result := []*Product{}
+	for _, chunk := range chunks {
+		result = append(result, chunk...)
+	}
+
+	return result
+}
@@ -0,0 +1,27 @@
+package solid
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterParallelKeepsTheOrder(t *testing.T) {
+	products := []Product{}
+	for i := 0; i < 5*minParallelChunk; i++ {
+		products = append(products, NewProduct(fmt.Sprintf("product %d", i), green, small, WithPrice(float64(i%7))))
+	}
+	bf := &BetterFilter{}
+	spec := PriceRangeSpecification{2, 4}
+
+	want := bf.Filter(products, spec)
+	got := bf.FilterParallel(products, spec, 4)
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d products, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("product %d is %q, want %q", i, got[i].name, want[i].name)
+		}
+	}
+}
@@ -1,80 +1,181 @@
 package solid
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
 
+// A Document is made of pages, plus some metadata like its author
 type Document struct {
+	Title    string
+	Pages    []string
+	Metadata map[string]string
+}
+
+// NewDocument copies the pages, so the caller can't change the document afterwards
+func NewDocument(title string, pages ...string) Document {
+	return Document{Title: title, Pages: append([]string{}, pages...), Metadata: map[string]string{}}
+}
+
+// clone copies the pages and metadata, so the copy shares nothing with the original
+func (d Document) clone() Document {
+	if d.Pages != nil {
+		d.Pages = append([]string{}, d.Pages...)
+	}
+	if d.Metadata != nil {
+		metadata := make(map[string]string, len(d.Metadata))
+		for k, v := range d.Metadata {
+			metadata[k] = v
+		}
+		d.Metadata = metadata
+	}
+
+	return d
+}
+
+func (d Document) String() string {
+	return fmt.Sprintf("%q (%d page(s))", d.Title, len(d.Pages))
+}
+
+// Devices used to just print their failures, now they return them so callers can react
+var (
+	ErrUnsupported   = errors.New("operation not supported")
+	ErrEmptyDocument = errors.New("document has no pages")
+	ErrPaperJam      = errors.New("paper jam")
+)
+
+// UnsupportedError tells which device can't do what, and is ErrUnsupported for errors.Is
+type UnsupportedError struct {
+	Device    string
+	Operation string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s can't %s: %v", e.Device, e.Operation, ErrUnsupported)
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return ErrUnsupported
 }
 
 type Machine interface {
-	Print(d Document)
-	Fax(d Document)
-	Scan(d Document)
+	Print(d Document) error
+	Fax(d Document) error
+	Scan() (Document, error)
+}
+
+// Every device checks the document the same way before working on it
+func checkDocument(d Document) error {
+	if len(d.Pages) == 0 {
+		return fmt.Errorf("%w: %q", ErrEmptyDocument, d.Title)
+	}
+
+	return nil
+}
+
+// scannedDocument is what comes out of a device's scanner
+func scannedDocument(device string) Document {
+	d := NewDocument("Scanned document", "scanned page")
+	d.Metadata["scanner"] = device
+
+	return d
 }
 
 // Does it all
 type MultiFunctionPrinter struct {
 }
 
-func (mfp MultiFunctionPrinter) Print(d Document) {
-	fmt.Println("I'm a MultiFunctionPrinter printing a document!")
+func (mfp MultiFunctionPrinter) Print(d Document) error {
+	if err := checkDocument(d); err != nil {
+		return err
+	}
+	fmt.Println("I'm a MultiFunctionPrinter printing", d)
+	return nil
 }
 
-func (mfp MultiFunctionPrinter) Fax(d Document) {
-	fmt.Println("I'm a MultiFunctionPrinter faxing a document!")
+func (mfp MultiFunctionPrinter) Fax(d Document) error {
+	if err := checkDocument(d); err != nil {
+		return err
+	}
+	fmt.Println("I'm a MultiFunctionPrinter faxing", d)
+	return nil
 }
 
-func (mfp MultiFunctionPrinter) Scan(d Document) {
+func (mfp MultiFunctionPrinter) Scan() (Document, error) {
 	fmt.Println("I'm a MultiFunctionPrinter scanning a document!")
+	return scannedDocument("MultiFunctionPrinter"), nil
 }
 
 // Cannot scan or fax
 type OldFashionedPrinter struct {
 }
 
-func (ofp OldFashionedPrinter) Print(d Document) {
-	fmt.Println("I'm an OldFashionedPrinter printing a document!")
+func (ofp OldFashionedPrinter) Print(d Document) error {
+	if err := checkDocument(d); err != nil {
+		return err
+	}
+	fmt.Println("I'm an OldFashionedPrinter printing", d)
+	return nil
 }
 
 // Deprecated
-func (ofp OldFashionedPrinter) Fax(d Document) {
-	fmt.Println("Error! I'm an OldFashionedPrinter, I can't fax!")
+func (ofp OldFashionedPrinter) Fax(d Document) error {
+	return &UnsupportedError{"OldFashionedPrinter", "fax"}
 }
 
 // Deprecated
-func (ofp OldFashionedPrinter) Scan(d Document) {
-	fmt.Println("Error! I'm an OldFashionedPrinter, I can't scan!")
+func (ofp OldFashionedPrinter) Scan() (Document, error) {
+	return Document{}, &UnsupportedError{"OldFashionedPrinter", "scan"}
 }
 
 // If we keep just one interface with all methods, we force the OldFashionedPrinter to implement features it doesn't have
 // Therefore, it would be better to split the functionalities in different interfaces
 
 type Printer interface {
-	Print(d Document)
+	Print(d Document) error
 }
 
 type Scanner interface {
-	Scan(d Document)
+	Scan() (Document, error)
 }
 
 type Faxxer interface {
-	Fax(d Document)
+	Fax(d Document) error
 }
 
 // Not forced to implement anything it doesn't do
 type RegularPrinter struct{}
 
-func (rp RegularPrinter) Print(d Document) {
-	fmt.Println("I'm a printer printing a document!")
+func (rp RegularPrinter) Print(d Document) error {
+	if err := checkDocument(d); err != nil {
+		return err
+	}
+	fmt.Println("I'm a printer printing", d)
+	return nil
 }
 
 type Photocopier struct{}
 
-func (p Photocopier) Print(d Document) {
-	fmt.Println("I'm a Photocopier printing a document!")
+func (p Photocopier) Print(d Document) error {
+	if err := checkDocument(d); err != nil {
+		return err
+	}
+	fmt.Println("I'm a Photocopier printing", d)
+	return nil
 }
 
-func (p Photocopier) Scan(d Document) {
+func (p Photocopier) Scan() (Document, error) {
 	fmt.Println("I'm a Photocopier scanning a document!")
+	return scannedDocument("Photocopier"), nil
+}
+
+// Every office has one of these
+type JammedPrinter struct{}
+
+func (jp JammedPrinter) Print(d Document) error {
+	return ErrPaperJam
 }
 
 // go allows you to build composite interfaces
@@ -91,35 +192,119 @@ type MultiFunctionalMachine struct {
 }
 
 // And have interfaces as components to make use of polymorphism
-func (mfm MultiFunctionalMachine) Print(d Document) {
-	mfm.printer.Print(d)
+// Whatever goes wrong in a component comes back to the caller as it is
+func (mfm MultiFunctionalMachine) Print(d Document) error {
+	if mfm.printer == nil {
+		return &UnsupportedError{"MultiFunctionalMachine", "print"}
+	}
+
+	return mfm.printer.Print(d)
 }
 
-func (mfm MultiFunctionalMachine) Scan(d Document) {
-	mfm.scanner.Scan(d)
+func (mfm MultiFunctionalMachine) Scan() (Document, error) {
+	if mfm.scanner == nil {
+		return Document{}, &UnsupportedError{"MultiFunctionalMachine", "scan"}
+	}
+
+	return mfm.scanner.Scan()
+}
+
+// report prints what went wrong, if anything did
+func report(err error) {
+	var unsupported *UnsupportedError
+	switch {
+	case errors.As(err, &unsupported):
+		fmt.Printf("Error! %s can't %s\n", unsupported.Device, unsupported.Operation)
+	case err != nil:
+		fmt.Println("Error!", err)
+	}
 }
 
 func Isp() {
-	doc := Document{}
+	doc := NewDocument("Report", "first page", "second page")
+	doc.Metadata["author"] = "pedr0diniz"
 
 	mfp := MultiFunctionPrinter{}
 	fmt.Println("What can the multifunction printer do?")
-	mfp.Print(doc)
-	mfp.Fax(doc)
-	mfp.Scan(doc)
+	report(mfp.Print(doc))
+	report(mfp.Fax(doc))
+	scanned, err := mfp.Scan()
+	report(err)
 
 	ofp := OldFashionedPrinter{}
 	fmt.Println("\nWhat can the old-fashioned printer do?")
-	ofp.Print(doc)
-	ofp.Fax(doc)
-	ofp.Scan(doc)
+	report(ofp.Print(scanned))
+	report(ofp.Fax(doc))
+	_, err = ofp.Scan()
+	report(err)
 
 	rp := RegularPrinter{}
 	fmt.Println("\nWhat can the regular printer do?")
-	rp.Print(doc)
+	report(rp.Print(doc))
+	report(rp.Print(NewDocument("Blank")))
 
 	pc := Photocopier{}
 	fmt.Println("\nWhat can the photocopier do?")
-	pc.Print(doc)
-	pc.Scan(doc)
+	report(pc.Print(doc))
+	_, err = pc.Scan()
+	report(err)
+
+	// The machine hands its errors over from the printer and the scanner it's made of
+	fmt.Println("\nWhat can a machine made of an old-fashioned printer do?")
+	mfm := MultiFunctionalMachine{printer: ofp, scanner: ofp}
+	report(mfm.Print(NewDocument("Blank")))
+	_, err = mfm.Scan()
+	if errors.Is(err, ErrUnsupported) {
+		fmt.Println("Its scanner is not supported:", err)
+	}
+
+	// Instead of guessing, we can register the devices and ask for the ones that can do what we need
+	registry := NewDeviceRegistry()
+	registry.Register("multifunction printer", mfp)
+	registry.Register("old-fashioned printer", ofp)
+	registry.Register("regular printer", rp)
+	registry.Register("photocopier", pc)
+	registry.Register("multifunctional machine", MultiFunctionalMachine{rp, pc})
+
+	fmt.Println("\nEverything that can print and scan:")
+	for _, d := range registry.Find(CanPrint | CanScan) {
+		fmt.Printf(" - %s (%s)\n", d.Name(), d.Capabilities())
+	}
+
+	// The old-fashioned printer still has a Fax method, but it doesn't claim it, so nobody gets to call it
+	fmt.Println("\nEverything that can fax:")
+	for _, d := range registry.Find(CanFax) {
+		if faxxer, ok := d.Faxxer(); ok {
+			report(faxxer.Fax(doc))
+		}
+	}
+
+	// An office has a bunch of devices, and people sending jobs to them
+	// The queue finds a free device for each job, and tries another one when it fails
+	office := NewDeviceRegistry()
+	office.Register("jammed printer", JammedPrinter{})
+	office.Register("photocopier", pc)
+	office.Register("multifunction printer", mfp)
+	queue := NewJobQueue(office)
+	defer queue.Close()
+
+	fmt.Println("\nSending jobs to the office devices:")
+	ctx := context.Background()
+	for _, kind := range []JobKind{PrintJob, ScanJob, FaxJob} {
+		job, err := queue.Submit(ctx, kind, doc)
+		if err != nil {
+			report(err)
+			continue
+		}
+		_, err = job.Wait(ctx)
+		fmt.Printf("The %s job is %s after trying %s\n", kind, job.Status(), strings.Join(job.Attempts(), ", then "))
+		report(err)
+	}
+
+	// Jobs can be cancelled through their context, as long as no device took them yet
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := queue.Submit(cancelled, PrintJob, doc); err != nil {
+		fmt.Println("The cancelled job was not sent:", err)
+	}
 }
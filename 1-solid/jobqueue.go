@@ -0,0 +1,328 @@
+package solid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// A MultiFunctionalMachine calls one printer and one scanner, and waits for them
+// A JobQueue takes print, scan and fax jobs and hands each one to a free device that can do it,
+// from the devices of a DeviceRegistry, trying another device when one fails
+
+type JobKind int
+
+const (
+	PrintJob JobKind = iota
+	ScanJob
+	FaxJob
+)
+
+var jobKinds = map[JobKind]struct {
+	name       string
+	capability Capability
+}{
+	PrintJob: {"print", CanPrint},
+	ScanJob:  {"scan", CanScan},
+	FaxJob:   {"fax", CanFax},
+}
+
+func (k JobKind) String() string {
+	if kind, ok := jobKinds[k]; ok {
+		return kind.name
+	}
+
+	return fmt.Sprintf("JobKind(%d)", int(k))
+}
+
+type JobStatus int
+
+const (
+	JobQueued JobStatus = iota
+	JobRunning
+	JobDone
+	JobFailed
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	}
+
+	return fmt.Sprintf("JobStatus(%d)", int(s))
+}
+
+var (
+	ErrQueueClosed = errors.New("job queue closed")
+	ErrNoDevice    = errors.New("no device can do this job")
+	ErrUnknownJob  = errors.New("unknown job kind")
+)
+
+type Job struct {
+	id       int
+	kind     JobKind
+	document Document
+	ctx      context.Context
+
+	mu      sync.Mutex
+	status  JobStatus
+	tried   []string
+	result  Document
+	err     error
+	settled chan struct{}
+}
+
+func (j *Job) ID() int       { return j.id }
+func (j *Job) Kind() JobKind { return j.kind }
+
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.status
+}
+
+// Attempts lists the devices that took the job, in order
+func (j *Job) Attempts() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]string{}, j.tried...)
+}
+
+// Wait blocks until the job is done or failed, and returns the scanned document for scan jobs
+// A done ctx only stops the waiting, not the job
+func (j *Job) Wait(ctx context.Context) (Document, error) {
+	select {
+	case <-j.settled:
+	case <-ctx.Done():
+		return Document{}, ctx.Err()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.result, j.err
+}
+
+func (j *Job) triedOn(device string) bool {
+	for _, name := range j.tried {
+		if name == device {
+			return true
+		}
+	}
+
+	return false
+}
+
+type JobQueue struct {
+	mu          sync.Mutex
+	devices     []DeviceHandle
+	busy        map[string]bool
+	pending     []*Job
+	lastID      int
+	closed      bool
+	maxAttempts int
+	// unsettled counts the jobs that aren't done or failed yet, for Close
+	unsettled sync.WaitGroup
+}
+
+type JobQueueOption func(*JobQueue)
+
+// WithMaxAttempts caps how many devices a job is tried on, by default it's every device that can do it
+func WithMaxAttempts(attempts int) JobQueueOption {
+	return func(q *JobQueue) {
+		q.maxAttempts = attempts
+	}
+}
+
+// NewJobQueue uses the devices in the registry at the time it's created
+func NewJobQueue(registry *DeviceRegistry, options ...JobQueueOption) *JobQueue {
+	q := &JobQueue{devices: registry.Find(0), busy: map[string]bool{}}
+	for _, option := range options {
+		option(q)
+	}
+
+	return q
+}
+
+// Submit queues a job, cancelling ctx takes it out of the queue
+// The job gets its own copy of the document, as devices read it later from other goroutines
+// A job that is already running can't be interrupted, as devices don't take a context, but it won't be retried
+func (q *JobQueue) Submit(ctx context.Context, kind JobKind, d Document) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// An unknown kind needs no capability, so every device would look able to do it
+	if _, ok := jobKinds[kind]; !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownJob, kind)
+	}
+	if q.capableDevices(kind) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoDevice, kind)
+	}
+
+	q.lastID++
+	job := &Job{id: q.lastID, kind: kind, document: d.clone(), ctx: ctx, settled: make(chan struct{})}
+	q.pending = append(q.pending, job)
+	q.unsettled.Add(1)
+	go q.watch(job)
+	q.dispatch()
+
+	return job, nil
+}
+
+func (q *JobQueue) capableDevices(kind JobKind) int {
+	count := 0
+	for _, d := range q.devices {
+		if d.Capabilities().Has(jobKinds[kind].capability) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// watch fails the job when its context is done while it's still waiting for a device
+func (q *JobQueue) watch(job *Job) {
+	select {
+	case <-job.settled:
+	case <-job.ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		for i, pending := range q.pending {
+			if pending == job {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				q.settle(job, Document{}, job.ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+// dispatch gives the waiting jobs, oldest first, to the first free device able to do them that they haven't failed on
+// It expects the lock to be held
+func (q *JobQueue) dispatch() {
+	waiting := []*Job{}
+	for _, job := range q.pending {
+		// Cancelled jobs are left for watch to fail
+		device, ok := q.freeDevice(job)
+		if !ok || job.ctx.Err() != nil {
+			waiting = append(waiting, job)
+			continue
+		}
+
+		q.busy[device.Name()] = true
+		job.mu.Lock()
+		job.status = JobRunning
+		job.tried = append(job.tried, device.Name())
+		job.mu.Unlock()
+		go q.run(job, device)
+	}
+	q.pending = waiting
+}
+
+func (q *JobQueue) freeDevice(job *Job) (DeviceHandle, bool) {
+	for _, d := range q.devices {
+		if !q.busy[d.Name()] && d.Capabilities().Has(jobKinds[job.kind].capability) && !job.triedOn(d.Name()) {
+			return d, true
+		}
+	}
+
+	return DeviceHandle{}, false
+}
+
+func (q *JobQueue) run(job *Job, device DeviceHandle) {
+	result, err := runOn(device, job.kind, job.document)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.busy[device.Name()] = false
+	if err != nil && q.retriable(job, err) {
+		job.mu.Lock()
+		job.status = JobQueued
+		job.mu.Unlock()
+		q.requeue(job)
+	} else {
+		q.settle(job, result, err)
+	}
+	q.dispatch()
+}
+
+func runOn(device DeviceHandle, kind JobKind, d Document) (Document, error) {
+	switch kind {
+	case PrintJob:
+		printer, _ := device.Printer()
+		return Document{}, printer.Print(d)
+	case ScanJob:
+		scanner, _ := device.Scanner()
+		return scanner.Scan()
+	case FaxJob:
+		faxxer, _ := device.Faxxer()
+		return Document{}, faxxer.Fax(d)
+	}
+
+	return Document{}, fmt.Errorf("unknown job kind %v", kind)
+}
+
+// An empty document fails on every device, and a cancelled job shouldn't go anywhere else
+func (q *JobQueue) retriable(job *Job, err error) bool {
+	if errors.Is(err, ErrEmptyDocument) || job.ctx.Err() != nil {
+		return false
+	}
+
+	attempts := len(job.tried)
+	if q.maxAttempts > 0 && attempts >= q.maxAttempts {
+		return false
+	}
+
+	return attempts < q.capableDevices(job.kind)
+}
+
+// requeue puts the job back in its place, so retries don't go behind newer jobs
+func (q *JobQueue) requeue(job *Job) {
+	i := 0
+	for i < len(q.pending) && q.pending[i].id < job.id {
+		i++
+	}
+	q.pending = append(q.pending, nil)
+	copy(q.pending[i+1:], q.pending[i:])
+	q.pending[i] = job
+}
+
+func (q *JobQueue) settle(job *Job, result Document, err error) {
+	job.mu.Lock()
+	job.result, job.err = result, err
+	job.status = JobDone
+	if err != nil {
+		job.status = JobFailed
+	}
+	job.mu.Unlock()
+
+	close(job.settled)
+	q.unsettled.Done()
+}
+
+// Close stops taking jobs, and waits for the ones already submitted to be done or failed
+func (q *JobQueue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	q.unsettled.Wait()
+}
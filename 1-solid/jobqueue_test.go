@@ -0,0 +1,239 @@
+package solid
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPrinter waits for release before printing, when it has one, and remembers what it printed
+type testPrinter struct {
+	err     error
+	release chan struct{}
+
+	mu      sync.Mutex
+	printed []Document
+}
+
+func (tp *testPrinter) Print(d Document) error {
+	if tp.release != nil {
+		<-tp.release
+	}
+	if err := checkDocument(d); err != nil {
+		return err
+	}
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.printed = append(tp.printed, d.clone())
+
+	return tp.err
+}
+
+func (tp *testPrinter) Printed() []Document {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	return append([]Document{}, tp.printed...)
+}
+
+func newTestQueue(t *testing.T, devices map[string]any, order []string, options ...JobQueueOption) *JobQueue {
+	t.Helper()
+
+	registry := NewDeviceRegistry()
+	for _, name := range order {
+		if _, err := registry.Register(name, devices[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewJobQueue(registry, options...)
+}
+
+func waitJob(t *testing.T, job *Job) (Document, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := job.Wait(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("job %d never settled", job.ID())
+	}
+
+	return result, err
+}
+
+func TestJobQueueRetriesOnAnotherDevice(t *testing.T) {
+	jammed, working := &testPrinter{err: ErrPaperJam}, &testPrinter{}
+	q := newTestQueue(t, map[string]any{"jammed": jammed, "working": working}, []string{"jammed", "working"})
+	defer q.Close()
+
+	job, err := q.Submit(context.Background(), PrintJob, NewDocument("report", "page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitJob(t, job); err != nil {
+		t.Fatalf("got %v, want the job done", err)
+	}
+
+	if job.Status() != JobDone {
+		t.Errorf("got status %s, want %s", job.Status(), JobDone)
+	}
+	if want := []string{"jammed", "working"}; !reflect.DeepEqual(job.Attempts(), want) {
+		t.Errorf("got attempts %v, want %v", job.Attempts(), want)
+	}
+}
+
+func TestJobQueueMaxAttempts(t *testing.T) {
+	devices := map[string]any{"first": &testPrinter{err: ErrPaperJam}, "second": &testPrinter{err: ErrPaperJam}}
+	q := newTestQueue(t, devices, []string{"first", "second"}, WithMaxAttempts(1))
+	defer q.Close()
+
+	job, err := q.Submit(context.Background(), PrintJob, NewDocument("report", "page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitJob(t, job); !errors.Is(err, ErrPaperJam) {
+		t.Errorf("got %v, want %v", err, ErrPaperJam)
+	}
+
+	if job.Status() != JobFailed {
+		t.Errorf("got status %s, want %s", job.Status(), JobFailed)
+	}
+	if want := []string{"first"}; !reflect.DeepEqual(job.Attempts(), want) {
+		t.Errorf("got attempts %v, want %v", job.Attempts(), want)
+	}
+}
+
+func TestJobQueueEmptyDocumentIsNotRetried(t *testing.T) {
+	first, second := &testPrinter{}, &testPrinter{}
+	q := newTestQueue(t, map[string]any{"first": first, "second": second}, []string{"first", "second"})
+	defer q.Close()
+
+	job, err := q.Submit(context.Background(), PrintJob, NewDocument("blank"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitJob(t, job); !errors.Is(err, ErrEmptyDocument) {
+		t.Errorf("got %v, want %v", err, ErrEmptyDocument)
+	}
+
+	if want := []string{"first"}; !reflect.DeepEqual(job.Attempts(), want) {
+		t.Errorf("got attempts %v, want %v", job.Attempts(), want)
+	}
+}
+
+func TestJobQueueCancelWhileQueued(t *testing.T) {
+	busy := &testPrinter{release: make(chan struct{})}
+	q := newTestQueue(t, map[string]any{"busy": busy}, []string{"busy"})
+	defer q.Close()
+
+	// The first job keeps the only printer busy, so the second one has to wait
+	running, err := q.Submit(context.Background(), PrintJob, NewDocument("first", "page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	queued, err := q.Submit(ctx, PrintJob, NewDocument("second", "page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued.Status() != JobQueued {
+		t.Errorf("got status %s, want %s", queued.Status(), JobQueued)
+	}
+
+	cancel()
+	if _, err := waitJob(t, queued); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if queued.Status() != JobFailed || len(queued.Attempts()) != 0 {
+		t.Errorf("got status %s after %v, want %s without attempts", queued.Status(), queued.Attempts(), JobFailed)
+	}
+
+	close(busy.release)
+	if _, err := waitJob(t, running); err != nil {
+		t.Errorf("got %v, want the running job done", err)
+	}
+	if got := len(busy.Printed()); got != 1 {
+		t.Errorf("the printer printed %d documents, want 1", got)
+	}
+}
+
+func TestJobQueueCloseDrainsPendingJobs(t *testing.T) {
+	busy := &testPrinter{release: make(chan struct{})}
+	q := newTestQueue(t, map[string]any{"busy": busy}, []string{"busy"})
+
+	jobs := []*Job{}
+	for i := 0; i < 3; i++ {
+		job, err := q.Submit(context.Background(), PrintJob, NewDocument("report", "page"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		q.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the jobs were done")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := q.Submit(context.Background(), PrintJob, NewDocument("late", "page")); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("submitting after Close: got %v, want %v", err, ErrQueueClosed)
+	}
+
+	close(busy.release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close never returned")
+	}
+	for _, job := range jobs {
+		if job.Status() != JobDone {
+			t.Errorf("job %d: got status %s, want %s", job.ID(), job.Status(), JobDone)
+		}
+	}
+}
+
+func TestJobQueueRejectsUnknownKind(t *testing.T) {
+	q := newTestQueue(t, map[string]any{"printer": &testPrinter{}}, []string{"printer"})
+	defer q.Close()
+
+	if _, err := q.Submit(context.Background(), JobKind(42), NewDocument("report", "page")); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("got %v, want %v", err, ErrUnknownJob)
+	}
+}
+
+func TestJobQueueCopiesTheDocument(t *testing.T) {
+	printer := &testPrinter{release: make(chan struct{})}
+	q := newTestQueue(t, map[string]any{"printer": printer}, []string{"printer"})
+	defer q.Close()
+
+	d := NewDocument("report", "original")
+	d.Metadata["author"] = "Pedro"
+	job, err := q.Submit(context.Background(), PrintJob, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The printer hasn't read the document yet, so these would race with it without the copy
+	d.Pages[0] = "edited"
+	d.Metadata["author"] = "someone else"
+	close(printer.release)
+	if _, err := waitJob(t, job); err != nil {
+		t.Fatal(err)
+	}
+
+	printed := printer.Printed()
+	if len(printed) != 1 || !reflect.DeepEqual(printed[0].Pages, []string{"original"}) || printed[0].Metadata["author"] != "Pedro" {
+		t.Errorf("printed %v, want the document as it was submitted", printed)
+	}
+}
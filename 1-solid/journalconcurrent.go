@@ -0,0 +1,245 @@
+package solid
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A Journal on its own isn't safe to share between goroutines
+// Rather than sprinkling locks all over the Journal, ConcurrentJournal wraps one and guards every access
+// It also tells subscribers about every change, so nobody has to poll the journal to find out what's new
+
+type ChangeKind int
+
+const (
+	EntryAdded ChangeKind = iota
+	EntryUpdated
+	EntryRemoved
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case EntryAdded:
+		return "added"
+	case EntryUpdated:
+		return "updated"
+	case EntryRemoved:
+		return "removed"
+	}
+
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// ChangeEvent carries a copy of the entry after the change, or as it was right before being removed
+type ChangeEvent struct {
+	Kind  ChangeKind
+	Entry Entry
+}
+
+type ConcurrentJournal struct {
+	mu          sync.RWMutex
+	journal     *Journal
+	subscribers map[int]*subscriber
+	nextSubID   int
+}
+
+func NewConcurrentJournal() *ConcurrentJournal {
+	return NewConcurrentJournalFrom(&Journal{})
+}
+
+// NewConcurrentJournalFrom starts from a copy of j, e.g. one loaded from a JournalStore
+func NewConcurrentJournalFrom(j *Journal) *ConcurrentJournal {
+	return &ConcurrentJournal{journal: j.clone(), subscribers: map[int]*subscriber{}}
+}
+
+func (cj *ConcurrentJournal) AddEntry(text string, options ...EntryOption) int {
+	cj.mu.Lock()
+	defer cj.mu.Unlock()
+
+	id := cj.journal.AddEntry(text, options...)
+	e, _ := cj.journal.GetEntry(id)
+	cj.publish(ChangeEvent{EntryAdded, e})
+
+	return id
+}
+
+func (cj *ConcurrentJournal) UpdateEntry(id int, text string) error {
+	cj.mu.Lock()
+	defer cj.mu.Unlock()
+
+	if err := cj.journal.UpdateEntry(id, text); err != nil {
+		return err
+	}
+	e, _ := cj.journal.GetEntry(id)
+	cj.publish(ChangeEvent{EntryUpdated, e})
+
+	return nil
+}
+
+func (cj *ConcurrentJournal) RemoveEntry(id int) error {
+	cj.mu.Lock()
+	defer cj.mu.Unlock()
+
+	e, err := cj.journal.GetEntry(id)
+	if err != nil {
+		return err
+	}
+	if err := cj.journal.RemoveEntry(id); err != nil {
+		return err
+	}
+	cj.publish(ChangeEvent{EntryRemoved, e})
+
+	return nil
+}
+
+func (cj *ConcurrentJournal) GetEntry(id int) (Entry, error) {
+	cj.mu.RLock()
+	defer cj.mu.RUnlock()
+
+	return cj.journal.GetEntry(id)
+}
+
+func (cj *ConcurrentJournal) Entries() []Entry {
+	cj.mu.RLock()
+	defer cj.mu.RUnlock()
+
+	return cj.journal.Entries()
+}
+
+func (cj *ConcurrentJournal) Query(spec EntrySpecification) []Entry {
+	cj.mu.RLock()
+	defer cj.mu.RUnlock()
+
+	return cj.journal.Query(spec)
+}
+
+func (cj *ConcurrentJournal) String() string {
+	cj.mu.RLock()
+	defer cj.mu.RUnlock()
+
+	return cj.journal.String()
+}
+
+// Snapshot returns a copy of the journal as it is right now, ready to be handed to a JournalStore
+func (cj *ConcurrentJournal) Snapshot() *Journal {
+	cj.mu.RLock()
+	defer cj.mu.RUnlock()
+
+	return cj.journal.clone()
+}
+
+// Subscribe calls fn for every change, in the order the changes happened
+// fn runs on its own goroutine, so it may call back into the journal, and a slow fn never blocks writers
+// Calling the returned function stops the subscription
+func (cj *ConcurrentJournal) Subscribe(fn func(ChangeEvent)) (unsubscribe func()) {
+	return cj.subscribe(newSubscriber(
+		func(e ChangeEvent, _ <-chan struct{}) bool {
+			fn(e)
+			return true
+		},
+		func() {},
+	))
+}
+
+// SubscribeChan delivers every change on the returned channel, in the order the changes happened
+// The channel is closed once the returned function is called
+func (cj *ConcurrentJournal) SubscribeChan() (events <-chan ChangeEvent, unsubscribe func()) {
+	ch := make(chan ChangeEvent)
+	unsubscribe = cj.subscribe(newSubscriber(
+		func(e ChangeEvent, done <-chan struct{}) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-done:
+				return false
+			}
+		},
+		func() { close(ch) },
+	))
+
+	return ch, unsubscribe
+}
+
+func (cj *ConcurrentJournal) subscribe(s *subscriber) func() {
+	cj.mu.Lock()
+	defer cj.mu.Unlock()
+
+	cj.nextSubID++
+	id := cj.nextSubID
+	cj.subscribers[id] = s
+
+	return func() {
+		cj.mu.Lock()
+		delete(cj.subscribers, id)
+		cj.mu.Unlock()
+		s.close()
+	}
+}
+
+// publish is called while holding the write lock, so events are queued in the same order as the changes
+func (cj *ConcurrentJournal) publish(e ChangeEvent) {
+	for _, s := range cj.subscribers {
+		s.push(ChangeEvent{e.Kind, e.Entry.clone()})
+	}
+}
+
+// Every subscriber gets its own queue and goroutine
+// Queuing never blocks, so a subscriber that falls behind can't stall the journal or the other subscribers
+type subscriber struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []ChangeEvent
+	closed bool
+	done   chan struct{}
+}
+
+func newSubscriber(deliver func(e ChangeEvent, done <-chan struct{}) bool, finish func()) *subscriber {
+	s := &subscriber{done: make(chan struct{})}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run(deliver, finish)
+
+	return s
+}
+
+func (s *subscriber) push(e ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.queue = append(s.queue, e)
+		s.cond.Signal()
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+		s.cond.Signal()
+	}
+}
+
+func (s *subscriber) run(deliver func(e ChangeEvent, done <-chan struct{}) bool, finish func()) {
+	defer finish()
+
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		if !deliver(e, s.done) {
+			return
+		}
+	}
+}
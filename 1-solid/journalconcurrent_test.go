@@ -0,0 +1,128 @@
+package solid
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// checkEventOrder checks the events could only have come in the order the changes happened:
+// IDs are handed out in order, and every entry is added, then updated, then removed
+func checkEventOrder(t *testing.T, name string, events []ChangeEvent) {
+	t.Helper()
+
+	lastAdded := 0
+	lastKind := map[int]ChangeKind{}
+	for i, e := range events {
+		id := e.Entry.ID
+		kind, seen := lastKind[id]
+		switch {
+		case e.Kind == EntryAdded && (seen || id <= lastAdded):
+			t.Fatalf("%s: event %d adds entry %d out of order", name, i, id)
+		case e.Kind != EntryAdded && (!seen || kind != e.Kind-1):
+			t.Fatalf("%s: event %d has entry %d %s out of order", name, i, id, e.Kind)
+		}
+		if e.Kind == EntryAdded {
+			lastAdded = id
+		}
+		lastKind[id] = e.Kind
+	}
+}
+
+func TestConcurrentJournalEventOrder(t *testing.T) {
+	const writers, changes = 8, 50
+	const total = writers * changes * 3
+
+	cj := NewConcurrentJournal()
+
+	mu := sync.Mutex{}
+	called := []ChangeEvent{}
+	allCalled := make(chan struct{})
+	unsubscribeFn := cj.Subscribe(func(e ChangeEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		called = append(called, e)
+		if len(called) == total {
+			close(allCalled)
+		}
+	})
+	defer unsubscribeFn()
+
+	events, unsubscribeChan := cj.SubscribeChan()
+	defer unsubscribeChan()
+	received := make(chan []ChangeEvent)
+	go func() {
+		result := []ChangeEvent{}
+		for e := range events {
+			result = append(result, e)
+			if len(result) == total {
+				received <- result
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for c := 0; c < changes; c++ {
+				id := cj.AddEntry(fmt.Sprintf("writer %d, change %d", w, c))
+				if err := cj.UpdateEntry(id, "updated"); err != nil {
+					t.Error(err)
+				}
+				if err := cj.RemoveEntry(id); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var fromChan []ChangeEvent
+	select {
+	case fromChan = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel subscriber didn't get every event")
+	}
+	select {
+	case <-allCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the function subscriber didn't get every event")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	checkEventOrder(t, "Subscribe", called)
+	checkEventOrder(t, "SubscribeChan", fromChan)
+	for i := range called {
+		if called[i].Kind != fromChan[i].Kind || called[i].Entry.ID != fromChan[i].Entry.ID {
+			t.Fatalf("event %d: Subscribe got entry %d %s, SubscribeChan got entry %d %s",
+				i, called[i].Entry.ID, called[i].Kind, fromChan[i].Entry.ID, fromChan[i].Kind)
+		}
+	}
+}
+
+func TestSubscribeChanClosesOnUnsubscribe(t *testing.T) {
+	cj := NewConcurrentJournal()
+	events, unsubscribe := cj.SubscribeChan()
+
+	// Nobody reads these, unsubscribing must not wait for them to be delivered
+	for i := 0; i < 3; i++ {
+		cj.AddEntry("unread")
+	}
+	unsubscribe()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("the channel wasn't closed after unsubscribing")
+		}
+	}
+}
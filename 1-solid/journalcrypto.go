@@ -0,0 +1,227 @@
+package solid
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Journals hold sensitive notes, so this persistence compresses them and encrypts them with a passphrase
+// It's a sibling of Persistence: the Journal doesn't know its entries end up encrypted
+//
+// The file starts with a header that is never encrypted, but is authenticated:
+//
+//	magic "JNLX" | version | scrypt cost: log2(N), r, p (a byte each) | salt | key check | nonce
+//
+// followed by the AES-GCM ciphertext of the gzipped JSON journal
+// Only version 1 exists so far, a new version can change any of it after the version byte
+
+const (
+	encryptedMagic      = "JNLX"
+	encryptedVersion    = 1
+	encryptedSaltSize   = 16
+	encryptedCheckSize  = 16
+	encryptedCostOffset = len(encryptedMagic) + 1
+	encryptedSaltOffset = encryptedCostOffset + 3
+	encryptedHeaderSize = encryptedSaltOffset + encryptedSaltSize + encryptedCheckSize
+
+	// scrypt needs 128 * r * N bytes of memory, we won't follow a header asking for more than this
+	maxScryptMemory = 1 << 30
+)
+
+// scrypt makes guessing passphrases expensive in memory as well as time, which GPUs are bad at
+// The cost is stored in the header, so it can grow later without breaking older files
+type scryptCost struct {
+	logN, r, p uint8
+}
+
+var defaultScryptCost = scryptCost{logN: 15, r: 8, p: 1}
+
+func (c scryptCost) valid() bool {
+	return c.logN > 0 && c.logN < 31 && c.r > 0 && c.p > 0 && 128*int64(c.r)<<c.logN <= maxScryptMemory
+}
+
+var (
+	// The key check covers the salt and scrypt cost too, so a changed header looks just like a wrong passphrase
+	ErrWrongPassphrase        = errors.New("wrong passphrase or tampered header")
+	ErrJournalTampered        = errors.New("encrypted journal is corrupted or was tampered with")
+	ErrUnsupportedJournalFile = errors.New("unsupported encrypted journal file")
+)
+
+type EncryptedPersistence struct {
+	passphrase []byte
+	cost       scryptCost
+}
+
+func NewEncryptedPersistence(passphrase string) *EncryptedPersistence {
+	return &EncryptedPersistence{[]byte(passphrase), defaultScryptCost}
+}
+
+// The file is only readable by its owner and replaced in one go, so a failed save never leaves half a journal
+func (ep *EncryptedPersistence) SaveToFile(j *Journal, filename string) error {
+	data, err := ep.seal(j)
+	if err != nil {
+		return err
+	}
+
+	tmp := filename + ".tmp"
+	if err := writeFileSynced(tmp, data, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+func (ep *EncryptedPersistence) LoadFromFile(filename string) (*Journal, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ep.open(data)
+}
+
+func (ep *EncryptedPersistence) seal(j *Journal) ([]byte, error) {
+	plain, err := json.Marshal(journalDocument{j.lastID, j.Entries()})
+	if err != nil {
+		return nil, err
+	}
+
+	compressed := bytes.Buffer{}
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, encryptedHeaderSize)
+	copy(header, encryptedMagic)
+	header[len(encryptedMagic)] = encryptedVersion
+	copy(header[encryptedCostOffset:], []byte{ep.cost.logN, ep.cost.r, ep.cost.p})
+	salt := header[encryptedSaltOffset : encryptedSaltOffset+encryptedSaltSize]
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	encKey, checkKey, err := deriveJournalKeys(ep.passphrase, salt, ep.cost)
+	if err != nil {
+		return nil, err
+	}
+	copy(header[encryptedHeaderSize-encryptedCheckSize:], keyCheck(checkKey, header))
+
+	gcm, err := newJournalGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	// The header and nonce are additional data, so changing any of them breaks decryption
+	out := append(header, nonce...)
+
+	return gcm.Seal(out, nonce, compressed.Bytes(), out), nil
+}
+
+func (ep *EncryptedPersistence) open(data []byte) (*Journal, error) {
+	if len(data) < encryptedHeaderSize || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, fmt.Errorf("%w: not an encrypted journal", ErrUnsupportedJournalFile)
+	}
+	if v := data[len(encryptedMagic)]; v != encryptedVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedJournalFile, v)
+	}
+
+	header := data[:encryptedHeaderSize]
+	cost := scryptCost{header[encryptedCostOffset], header[encryptedCostOffset+1], header[encryptedCostOffset+2]}
+	if !cost.valid() {
+		return nil, fmt.Errorf("%w: scrypt cost N=2^%d, r=%d, p=%d", ErrUnsupportedJournalFile, cost.logN, cost.r, cost.p)
+	}
+	salt := header[encryptedSaltOffset : encryptedSaltOffset+encryptedSaltSize]
+
+	// The key check tells a wrong passphrase or a changed header apart from a damaged body before we even try to decrypt
+	encKey, checkKey, err := deriveJournalKeys(ep.passphrase, salt, cost)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(keyCheck(checkKey, header), header[encryptedHeaderSize-encryptedCheckSize:]) {
+		return nil, ErrWrongPassphrase
+	}
+
+	gcm, err := newJournalGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < encryptedHeaderSize+gcm.NonceSize() {
+		return nil, ErrJournalTampered
+	}
+	aad := data[:encryptedHeaderSize+gcm.NonceSize()]
+	nonce := aad[encryptedHeaderSize:]
+
+	compressed, err := gcm.Open(nil, nonce, data[len(aad):], aad)
+	if err != nil {
+		return nil, ErrJournalTampered
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJournalTampered, err)
+	}
+	plain, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJournalTampered, err)
+	}
+
+	doc := journalDocument{}
+	if err := json.Unmarshal(plain, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJournalTampered, err)
+	}
+
+	return doc.journal()
+}
+
+func newJournalGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// keyCheck authenticates everything in the header before the check itself
+func keyCheck(checkKey, header []byte) []byte {
+	mac := hmac.New(sha256.New, checkKey)
+	mac.Write(header[:encryptedHeaderSize-encryptedCheckSize])
+
+	return mac.Sum(nil)[:encryptedCheckSize]
+}
+
+// deriveJournalKeys stretches the passphrase into an AES-256 key and a separate key for the passphrase check
+func deriveJournalKeys(passphrase, salt []byte, cost scryptCost) (encKey, checkKey []byte, err error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<cost.logN, int(cost.r), int(cost.p), 64)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key[:32], key[32:], nil
+}
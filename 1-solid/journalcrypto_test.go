@@ -0,0 +1,109 @@
+package solid
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Test vectors for scrypt from RFC 7914, section 12, to check the cost in the header reaches scrypt as it should
+func TestDeriveJournalKeys(t *testing.T) {
+	tests := []struct {
+		passphrase, salt string
+		cost             scryptCost
+		want             string
+	}{
+		{"", "", scryptCost{4, 1, 1}, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", scryptCost{10, 8, 16}, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+
+	for _, tt := range tests {
+		encKey, checkKey, err := deriveJournalKeys([]byte(tt.passphrase), []byte(tt.salt), tt.cost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(append(encKey, checkKey...)); got != tt.want {
+			t.Errorf("keys for %q and %q with %+v are %s, want %s", tt.passphrase, tt.salt, tt.cost, got, tt.want)
+		}
+	}
+}
+
+// A low cost keeps the tests fast, it's read back from the file anyway
+func testEncryptedPersistence(passphrase string) *EncryptedPersistence {
+	return &EncryptedPersistence{[]byte(passphrase), scryptCost{logN: 4, r: 8, p: 1}}
+}
+
+func sealTestJournal(t *testing.T) (*Journal, []byte) {
+	t.Helper()
+
+	j := &Journal{}
+	j.AddEntry("secret", WithTags("private"))
+	j.AddEntry("another secret")
+	j.RemoveEntry(2)
+
+	data, err := testEncryptedPersistence("correct horse").seal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return j, data
+}
+
+func TestEncryptedPersistenceRoundTrip(t *testing.T) {
+	j, data := sealTestJournal(t)
+
+	got, err := testEncryptedPersistence("correct horse").open(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != j.String() || got.lastID != j.lastID {
+		t.Errorf("got %q with last ID %d, want %q with last ID %d", got.String(), got.lastID, j.String(), j.lastID)
+	}
+}
+
+func TestEncryptedPersistenceChecksEntries(t *testing.T) {
+	j := &Journal{entries: []Entry{{ID: 1, Text: "a"}, {ID: 1, Text: "b"}}, lastID: 1}
+	data, err := testEncryptedPersistence("correct horse").seal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testEncryptedPersistence("correct horse").open(data); !errors.Is(err, ErrDuplicateEntryID) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateEntryID)
+	}
+}
+
+func TestEncryptedPersistenceErrors(t *testing.T) {
+	_, data := sealTestJournal(t)
+	flip := func(i int) []byte {
+		changed := append([]byte{}, data...)
+		changed[i] ^= 1
+		return changed
+	}
+	expensive := append([]byte{}, data...)
+	expensive[encryptedCostOffset] = 30
+
+	tests := []struct {
+		name       string
+		passphrase string
+		data       []byte
+		want       error
+	}{
+		{"wrong passphrase", "wrong horse", data, ErrWrongPassphrase},
+		{"changed salt", "correct horse", flip(encryptedSaltOffset + 5), ErrWrongPassphrase},
+		{"changed cost", "correct horse", flip(encryptedCostOffset), ErrWrongPassphrase},
+		{"cost too high", "correct horse", expensive, ErrUnsupportedJournalFile},
+		{"changed nonce", "correct horse", flip(encryptedHeaderSize), ErrJournalTampered},
+		{"changed ciphertext", "correct horse", flip(len(data) - 20), ErrJournalTampered},
+		{"cut short", "correct horse", data[:len(data)-1], ErrJournalTampered},
+		{"not encrypted", "correct horse", []byte("1: plain text"), ErrUnsupportedJournalFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := testEncryptedPersistence(tt.passphrase).open(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
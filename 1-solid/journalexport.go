@@ -0,0 +1,261 @@
+package solid
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedr0diniz/2-patterns/creational/builder"
+)
+
+// Exporting a journal is yet another responsibility the Journal shouldn't have
+// Each format gets its own exporter, and an importer wherever the format keeps enough information to read it back
+type JournalExporter interface {
+	Export(w io.Writer, j *Journal) error
+}
+
+type JournalImporter interface {
+	Import(r io.Reader) (*Journal, error)
+}
+
+const (
+	exportDayLayout  = "2006-01-02"
+	exportTimeLayout = "15:04:05"
+)
+
+// Days are split in the given location, UTC when none is given
+func exportLocation(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// MarkdownExporter writes a heading per day and a bullet per entry:
+//
+//	## 2022-05-01
+//
+//	- 10:30:00 #1 I'm pissed today
+//
+// Lines after the first one in an entry are indented, so they stay inside the bullet
+// Only the ID, creation time and text survive the trip
+type MarkdownExporter struct {
+	Location *time.Location
+}
+
+func (me MarkdownExporter) Export(w io.Writer, j *Journal) error {
+	loc := exportLocation(me.Location)
+	bw := bufio.NewWriter(w)
+
+	day := ""
+	for _, e := range j.Query(nil) {
+		created := e.Created.In(loc)
+		if d := created.Format(exportDayLayout); d != day {
+			if day != "" {
+				bw.WriteString("\n")
+			}
+			day = d
+			fmt.Fprintf(bw, "## %s\n\n", day)
+		}
+
+		text := strings.ReplaceAll(e.Text, "\n", "\n  ")
+		fmt.Fprintf(bw, "- %s #%d %s\n", created.Format(exportTimeLayout), e.ID, text)
+	}
+
+	return bw.Flush()
+}
+
+type MarkdownImporter struct {
+	Location *time.Location
+}
+
+func (mi MarkdownImporter) Import(r io.Reader) (*Journal, error) {
+	loc := exportLocation(mi.Location)
+	j := &Journal{}
+
+	var day time.Time
+	var current *Entry
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		err := j.restoreEntry(*current)
+		current = nil
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+
+		var err error
+		switch {
+		case strings.HasPrefix(text, "## "):
+			err = flush()
+			if err == nil {
+				day, err = time.ParseInLocation(exportDayLayout, strings.TrimPrefix(text, "## "), loc)
+			}
+		case strings.HasPrefix(text, "- "):
+			err = flush()
+			if err == nil {
+				current, err = parseMarkdownEntry(strings.TrimPrefix(text, "- "), day, loc)
+			}
+		case strings.HasPrefix(text, "  ") && current != nil:
+			current.Text += "\n" + strings.TrimPrefix(text, "  ")
+		case strings.TrimSpace(text) == "":
+		default:
+			err = ErrMalformedEntry
+		}
+
+		if err != nil {
+			return nil, &ParseError{Line: line, Text: text, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := flush(); err != nil {
+		return nil, &ParseError{Line: line, Err: err}
+	}
+
+	return j, nil
+}
+
+func parseMarkdownEntry(bullet string, day time.Time, loc *time.Location) (*Entry, error) {
+	if day.IsZero() {
+		return nil, fmt.Errorf("%w: entry before any day heading", ErrMalformedEntry)
+	}
+
+	parts := strings.SplitN(bullet, " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[1], "#") {
+		return nil, ErrMalformedEntry
+	}
+
+	clock, err := time.Parse(exportTimeLayout, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedEntry, err)
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedEntry, err)
+	}
+
+	text := ""
+	if len(parts) == 3 {
+		text = parts[2]
+	}
+
+	created := time.Date(day.Year(), day.Month(), day.Day(),
+		clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+
+	return &Entry{ID: id, Text: text, Created: created, Modified: created}, nil
+}
+
+// HTMLExporter reuses the HtmlBuilder from the builder pattern: a heading per day and a paragraph per entry
+// HTML is only meant to be read, so there's no importer for it
+type HTMLExporter struct {
+	Location *time.Location
+}
+
+func (he HTMLExporter) Export(w io.Writer, j *Journal) error {
+	loc := exportLocation(he.Location)
+	b := builder.NewHtmlBuilder("article")
+
+	day := ""
+	for _, e := range j.Query(nil) {
+		created := e.Created.In(loc)
+		if d := created.Format(exportDayLayout); d != day {
+			day = d
+			b.AddChild("h2", day)
+		}
+
+		text := fmt.Sprintf("%s %s", created.Format(exportTimeLayout), e.String())
+		b.AddChild("p", html.EscapeString(text))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+var csvHeader = []string{"id", "timestamp", "text"}
+
+// CSVExporter writes one row per entry with its ID, creation timestamp (RFC 3339) and text
+type CSVExporter struct{}
+
+func (CSVExporter) Export(w io.Writer, j *Journal) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, e := range j.Query(nil) {
+		row := []string{strconv.Itoa(e.ID), e.Created.Format(time.RFC3339Nano), e.Text}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+type CSVImporter struct{}
+
+func (CSVImporter) Import(r io.Reader) (*Journal, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &Journal{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(header, ",") != strings.Join(csvHeader, ",") {
+		return nil, &ParseError{Line: 1, Text: strings.Join(header, ","), Err: fmt.Errorf("%w: unexpected header", ErrMalformedEntry)}
+	}
+
+	j := &Journal{}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		if err := importCSVRow(j, row); err != nil {
+			return nil, &ParseError{Line: line, Text: strings.Join(row, ","), Err: err}
+		}
+	}
+
+	return j, nil
+}
+
+func importCSVRow(j *Journal, row []string) error {
+	id, err := strconv.Atoi(row[0])
+	if err != nil {
+		return fmt.Errorf("%w: bad id %q", ErrMalformedEntry, row[0])
+	}
+
+	created, err := time.Parse(time.RFC3339Nano, row[1])
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrMalformedEntry, row[1])
+	}
+
+	return j.restoreEntry(Entry{ID: id, Text: row[2], Created: created, Modified: created})
+}
@@ -0,0 +1,361 @@
+package solid
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Scanning every entry for a word gets slow once a journal is big
+// The SearchIndex keeps an inverted index next to the journal: for every term, which entries have it and how often
+// Like the exporters, it lives outside the Journal, which keeps doing just one thing
+
+type SearchResult struct {
+	ID    int
+	Score float64
+}
+
+type SearchIndex struct {
+	mu sync.RWMutex
+	// docs keeps the tokens of every entry, in order, for phrase queries and removals
+	docs map[int][]string
+	// postings maps each term to the entries containing it and how many times
+	postings map[string]map[int]int
+	// terms is postings' keys, sorted for prefix queries and rebuilt only when needed
+	terms      []string
+	termsDirty bool
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{docs: map[int][]string{}, postings: map[string]map[int]int{}}
+}
+
+// IndexJournal builds an index with every entry of the journal
+func IndexJournal(j *Journal) *SearchIndex {
+	si := NewSearchIndex()
+	for _, e := range j.entries {
+		si.Add(e)
+	}
+
+	return si
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add indexes an entry, replacing whatever was indexed before under the same ID
+func (si *SearchIndex) Add(e Entry) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(e.ID)
+	si.add(e.ID, tokenize(e.Text))
+}
+
+func (si *SearchIndex) Remove(id int) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(id)
+}
+
+func (si *SearchIndex) add(id int, tokens []string) {
+	si.docs[id] = tokens
+	for _, t := range tokens {
+		p, ok := si.postings[t]
+		if !ok {
+			p = map[int]int{}
+			si.postings[t] = p
+			si.termsDirty = true
+		}
+		p[id]++
+	}
+}
+
+func (si *SearchIndex) remove(id int) {
+	tokens, ok := si.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, t := range tokens {
+		p := si.postings[t]
+		delete(p, id)
+		if len(p) == 0 {
+			delete(si.postings, t)
+			si.termsDirty = true
+		}
+	}
+	delete(si.docs, id)
+}
+
+// Watch keeps the index up to date with a ConcurrentJournal until the returned function is called
+func (si *SearchIndex) Watch(cj *ConcurrentJournal) (stop func()) {
+	// Holding the lock makes the first events wait until the current entries are indexed,
+	// events for changes already in the snapshot just apply them again
+	si.mu.Lock()
+	stop = cj.Subscribe(func(e ChangeEvent) {
+		switch e.Kind {
+		case EntryAdded, EntryUpdated:
+			si.Add(e.Entry)
+		case EntryRemoved:
+			si.Remove(e.Entry.ID)
+		}
+	})
+	for _, e := range cj.Entries() {
+		si.remove(e.ID)
+		si.add(e.ID, tokenize(e.Text))
+	}
+	si.mu.Unlock()
+
+	return stop
+}
+
+// Search understands three kinds of clauses, separated by spaces:
+//
+//	term        entries with the term
+//	"a phrase"  entries with these terms next to each other
+//	pre*        entries with any term starting with "pre"
+//
+// Entries have to match every clause, and come back with the best TF-IDF score first
+func (si *SearchIndex) Search(query string) ([]SearchResult, error) {
+	clauses, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	var scores map[int]float64
+	for _, c := range clauses {
+		matches := si.match(c)
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for id := range scores {
+			if m, ok := matches[id]; ok {
+				scores[id] += m
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	result := []SearchResult{}
+	for id, score := range scores {
+		result = append(result, SearchResult{id, score})
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Score != result[b].Score {
+			return result[a].Score > result[b].Score
+		}
+		return result[a].ID < result[b].ID
+	})
+
+	return result, nil
+}
+
+type searchClause struct {
+	terms  []string
+	prefix bool
+}
+
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+func parseSearchQuery(query string) ([]searchClause, error) {
+	clauses := []searchClause{}
+
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		var word string
+		quoted := strings.HasPrefix(rest, `"`)
+		if quoted {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated phrase in %q", ErrInvalidSearchQuery, query)
+			}
+			word, rest = rest[1:end+1], rest[end+2:]
+		} else if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+			word, rest = rest[:i], rest[i:]
+		} else {
+			word, rest = rest, ""
+		}
+		rest = strings.TrimSpace(rest)
+
+		c := searchClause{terms: tokenize(word), prefix: !quoted && strings.HasSuffix(word, "*")}
+		if len(c.terms) == 0 {
+			continue
+		}
+		if c.prefix && len(c.terms) > 1 {
+			return nil, fmt.Errorf("%w: bad prefix %q", ErrInvalidSearchQuery, word)
+		}
+		clauses = append(clauses, c)
+	}
+
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("%w: nothing to search for", ErrInvalidSearchQuery)
+	}
+
+	return clauses, nil
+}
+
+// match returns the TF-IDF score of every entry matching the clause
+func (si *SearchIndex) match(c searchClause) map[int]float64 {
+	scores := map[int]float64{}
+
+	switch {
+	case c.prefix:
+		for _, t := range si.termsWithPrefix(c.terms[0]) {
+			for id, s := range si.termScores(t) {
+				scores[id] += s
+			}
+		}
+	case len(c.terms) == 1:
+		scores = si.termScores(c.terms[0])
+	default:
+		// A phrase matches entries that have all of its terms, in that exact order
+		for id, s := range si.termScores(c.terms[0]) {
+			if !containsPhrase(si.docs[id], c.terms) {
+				continue
+			}
+			for _, t := range c.terms[1:] {
+				s += si.postingScore(t, id)
+			}
+			scores[id] = s
+		}
+	}
+
+	return scores
+}
+
+func (si *SearchIndex) termScores(term string) map[int]float64 {
+	scores := map[int]float64{}
+	for id := range si.postings[term] {
+		scores[id] = si.postingScore(term, id)
+	}
+
+	return scores
+}
+
+func (si *SearchIndex) postingScore(term string, id int) float64 {
+	p := si.postings[term]
+	if len(p) == 0 || len(si.docs[id]) == 0 {
+		return 0
+	}
+
+	tf := float64(p[id]) / float64(len(si.docs[id]))
+	idf := math.Log(1 + float64(len(si.docs))/float64(len(p)))
+
+	return tf * idf
+}
+
+func containsPhrase(tokens, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		found := true
+		for k, t := range phrase {
+			if tokens[i+k] != t {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// termsWithPrefix expects the write lock, as it may have to rebuild the sorted terms
+func (si *SearchIndex) termsWithPrefix(prefix string) []string {
+	if si.termsDirty || si.terms == nil {
+		si.terms = make([]string, 0, len(si.postings))
+		for t := range si.postings {
+			si.terms = append(si.terms, t)
+		}
+		sort.Strings(si.terms)
+		si.termsDirty = false
+	}
+
+	start := sort.SearchStrings(si.terms, prefix)
+	end := start
+	for end < len(si.terms) && strings.HasPrefix(si.terms[end], prefix) {
+		end++
+	}
+
+	return si.terms[start:end]
+}
+
+// The index is persisted as the tokens of every entry, the postings are rebuilt when loading
+// The version lets us change what's stored later on
+const searchIndexVersion = 1
+
+type searchIndexFile struct {
+	Version int
+	Docs    map[int][]string
+}
+
+func (si *SearchIndex) Save(w io.Writer) error {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(searchIndexFile{searchIndexVersion, si.docs})
+}
+
+func LoadSearchIndex(r io.Reader) (*SearchIndex, error) {
+	f := searchIndexFile{}
+	if err := gob.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decoding search index: %w", err)
+	}
+	if f.Version != searchIndexVersion {
+		return nil, fmt.Errorf("unsupported search index version %d", f.Version)
+	}
+
+	si := NewSearchIndex()
+	for id, tokens := range f.Docs {
+		si.add(id, tokens)
+	}
+
+	return si, nil
+}
+
+// SearchIndexFilename is where the index of a journal file lives, right next to it
+func SearchIndexFilename(journalFilename string) string {
+	return journalFilename + ".idx"
+}
+
+func (si *SearchIndex) SaveToFile(journalFilename string) error {
+	file, err := os.Create(SearchIndexFilename(journalFilename))
+	if err != nil {
+		return err
+	}
+
+	if err := si.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func LoadSearchIndexFromFile(journalFilename string) (*SearchIndex, error) {
+	file, err := os.Open(SearchIndexFilename(journalFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadSearchIndex(file)
+}
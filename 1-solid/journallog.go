@@ -0,0 +1,348 @@
+package solid
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// Rewriting the whole file on every save gets slow once a journal is big
+// A JournalLog only appends what changed: every add, update and removal becomes a record at the end of the file
+// Opening the log replays the records to rebuild the journal, and Compact squashes them into a single snapshot
+
+// Each record is framed as: 4 bytes payload length, 4 bytes CRC-32 of the payload, then the JSON payload
+const logHeaderSize = 8
+
+var ErrLogCorrupted = errors.New("journal log corrupted")
+
+var logChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+type logOperation string
+
+const (
+	logAdd      logOperation = "add"
+	logUpdate   logOperation = "update"
+	logRemove   logOperation = "remove"
+	logSnapshot logOperation = "snapshot"
+)
+
+type logRecord struct {
+	Op       logOperation     `json:"op"`
+	Entry    *Entry           `json:"entry,omitempty"`
+	Snapshot *journalDocument `json:"snapshot,omitempty"`
+}
+
+// apply replays a record on the journal, the same way for live changes and for recovery
+func (j *Journal) apply(r logRecord) error {
+	switch r.Op {
+	case logAdd:
+		if r.Entry == nil {
+			break
+		}
+		return j.restoreEntry(*r.Entry)
+	case logUpdate:
+		if r.Entry == nil {
+			break
+		}
+		i, err := j.indexOf(r.Entry.ID)
+		if err != nil {
+			return err
+		}
+		j.entries[i].Text = r.Entry.Text
+		j.entries[i].Modified = r.Entry.Modified
+		return nil
+	case logRemove:
+		if r.Entry == nil {
+			break
+		}
+		return j.RemoveEntry(r.Entry.ID)
+	case logSnapshot:
+		if r.Snapshot == nil {
+			break
+		}
+		snapshot, err := r.Snapshot.journal()
+		if err != nil {
+			return err
+		}
+		j.entries, j.lastID = snapshot.entries, snapshot.lastID
+		return nil
+	}
+
+	return fmt.Errorf("invalid %q record", r.Op)
+}
+
+type JournalLog struct {
+	path    string
+	file    *os.File
+	size    int64
+	journal *Journal
+
+	recovered int64
+	// broken is set when the log can't be written anymore, see Compact
+	broken error
+}
+
+// OpenJournalLog replays the log at path, creating it if needed
+// A record that was only partially written when the process died is cut off,
+// but a damaged record followed by more data means the file is corrupted and we refuse to guess
+func OpenJournalLog(path string) (*JournalLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &JournalLog{path: path, file: file, journal: &Journal{}}
+	if err := l.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *JournalLog) replay() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(l.file)
+	var offset int64
+	for {
+		record, n, err := readLogRecord(r, info.Size()-offset)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, errTornRecord) {
+			// Whatever comes after the last good record never made it to disk completely
+			if err := l.file.Truncate(offset); err != nil {
+				return err
+			}
+			l.recovered = info.Size() - offset
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: record at offset %d: %v", ErrLogCorrupted, offset, err)
+		}
+		if err := l.journal.apply(record); err != nil {
+			return fmt.Errorf("%w: record at offset %d: %v", ErrLogCorrupted, offset, err)
+		}
+		offset += n
+	}
+
+	l.size = offset
+
+	return nil
+}
+
+var errTornRecord = errors.New("torn record")
+
+// remaining is how much of the file is left, so a garbled length can't make us allocate gigabytes
+func readLogRecord(r *bufio.Reader, remaining int64) (logRecord, int64, error) {
+	header := make([]byte, logHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return logRecord{}, 0, errTornRecord
+		}
+		return logRecord{}, 0, err
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[:4]))
+	if length > remaining-logHeaderSize {
+		// Either the last record was cut short, or the length itself is garbled
+		// It can only be the former when no good record follows, otherwise cutting here would throw that record away
+		rest, err := io.ReadAll(r)
+		if err != nil {
+			return logRecord{}, 0, err
+		}
+		if containsLogRecord(append(header, rest...)[1:]) {
+			return logRecord{}, 0, fmt.Errorf("length %d runs past the end of the log", length)
+		}
+		return logRecord{}, 0, errTornRecord
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return logRecord{}, 0, errTornRecord
+		}
+		return logRecord{}, 0, err
+	}
+
+	if crc32.Checksum(payload, logChecksumTable) != binary.BigEndian.Uint32(header[4:]) {
+		// A bad checksum on the very last record is a torn write, anywhere else it's corruption
+		if _, err := r.Peek(1); err == io.EOF {
+			return logRecord{}, 0, errTornRecord
+		}
+		return logRecord{}, 0, errors.New("checksum mismatch")
+	}
+
+	record := logRecord{}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return logRecord{}, 0, err
+	}
+
+	return record, int64(logHeaderSize + len(payload)), nil
+}
+
+// containsLogRecord looks for a whole record with a matching checksum starting anywhere in data
+func containsLogRecord(data []byte) bool {
+	for i := 0; i+logHeaderSize <= len(data); i++ {
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		end := i + logHeaderSize + length
+		if length == 0 || end > len(data) || end < i {
+			continue
+		}
+		if crc32.Checksum(data[i+logHeaderSize:end], logChecksumTable) == binary.BigEndian.Uint32(data[i+4:i+8]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func encodeLogRecord(record logRecord) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, logHeaderSize, logHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(data[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:], crc32.Checksum(payload, logChecksumTable))
+
+	return append(data, payload...), nil
+}
+
+// write appends the record and only then applies it, so the journal never gets ahead of the file
+func (l *JournalLog) write(record logRecord) error {
+	if l.broken != nil {
+		return l.broken
+	}
+
+	data, err := encodeLogRecord(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.file.Write(data)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// Don't leave half a record behind for the next write to append after
+		_ = l.file.Truncate(l.size)
+		return err
+	}
+	l.size += int64(len(data))
+
+	return l.journal.apply(record)
+}
+
+func (l *JournalLog) AddEntry(text string, options ...EntryOption) (int, error) {
+	e := l.journal.newEntry(text, options)
+	if err := l.write(logRecord{Op: logAdd, Entry: &e}); err != nil {
+		return 0, err
+	}
+
+	return e.ID, nil
+}
+
+func (l *JournalLog) UpdateEntry(id int, text string) error {
+	if _, err := l.journal.indexOf(id); err != nil {
+		return err
+	}
+
+	return l.write(logRecord{Op: logUpdate, Entry: &Entry{ID: id, Text: text, Modified: time.Now()}})
+}
+
+func (l *JournalLog) RemoveEntry(id int) error {
+	if _, err := l.journal.indexOf(id); err != nil {
+		return err
+	}
+
+	return l.write(logRecord{Op: logRemove, Entry: &Entry{ID: id}})
+}
+
+// Journal returns a copy of the current state, changes have to go through the log
+func (l *JournalLog) Journal() *Journal {
+	return l.journal.clone()
+}
+
+// Recovered tells how many bytes of a torn trailing record were discarded when the log was opened
+func (l *JournalLog) Recovered() int64 {
+	return l.recovered
+}
+
+// Compact rewrites the log as a single snapshot record
+// The snapshot goes to a temporary file first, so a crash halfway through leaves the old log intact
+func (l *JournalLog) Compact() error {
+	if l.broken != nil {
+		return l.broken
+	}
+
+	data, err := encodeLogRecord(logRecord{
+		Op:       logSnapshot,
+		Snapshot: &journalDocument{l.journal.lastID, l.journal.Entries()},
+	})
+	if err != nil {
+		return err
+	}
+
+	tmpPath := l.path + ".compact"
+	if err := writeFileSynced(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Our handle still points to the old log, so we swap it for the snapshot
+	// If that fails, writing through the old handle would go to a file nobody will ever read, so the log stops taking writes
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND, 0644)
+	l.file.Close()
+	if err != nil {
+		l.file = nil
+		l.broken = fmt.Errorf("journal log unusable after compaction: %w", err)
+		return l.broken
+	}
+	l.file = file
+	l.size = int64(len(data))
+
+	return nil
+}
+
+func writeFileSynced(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (l *JournalLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
@@ -0,0 +1,99 @@
+package solid
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestLog writes a log with n entries and returns its path and the offset of every record
+func writeTestLog(t *testing.T, n int) (string, []int64) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "journal.log")
+	l, err := OpenJournalLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offsets := []int64{}
+	for i := 0; i < n; i++ {
+		offsets = append(offsets, l.size)
+		if _, err := l.AddEntry("entry"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, offsets
+}
+
+func TestJournalLogGarbledLengthIsCorruption(t *testing.T) {
+	path, offsets := writeTestLog(t, 5)
+	data, _ := os.ReadFile(path)
+	binary.BigEndian.PutUint32(data[offsets[1]:], 1<<30)
+	os.WriteFile(path, data, 0644)
+
+	if _, err := OpenJournalLog(path); !errors.Is(err, ErrLogCorrupted) {
+		t.Fatalf("got %v, want %v", err, ErrLogCorrupted)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(data)) {
+		t.Errorf("the log was cut from %d to %d bytes", len(data), info.Size())
+	}
+}
+
+func TestJournalLogTornTail(t *testing.T) {
+	path, offsets := writeTestLog(t, 3)
+	data, _ := os.ReadFile(path)
+
+	tests := []struct {
+		name string
+		size int64
+	}{
+		{"half a header", offsets[2] + 3},
+		{"half a payload", offsets[2] + logHeaderSize + 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(path, data[:tt.size], 0644)
+
+			l, err := OpenJournalLog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			if got := len(l.Journal().Entries()); got != 2 {
+				t.Errorf("got %d entries, want 2", got)
+			}
+			if l.Recovered() != tt.size-offsets[2] {
+				t.Errorf("recovered %d bytes, want %d", l.Recovered(), tt.size-offsets[2])
+			}
+		})
+	}
+}
+
+func TestJournalLogSnapshotChecksEntries(t *testing.T) {
+	j := &Journal{}
+	j.AddEntry("kept")
+
+	duplicates := &journalDocument{2, []Entry{{ID: 1, Text: "a"}, {ID: 1, Text: "b"}}}
+	if err := j.apply(logRecord{Op: logSnapshot, Snapshot: duplicates}); !errors.Is(err, ErrDuplicateEntryID) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateEntryID)
+	}
+	if j.String() != "1: kept" {
+		t.Errorf("a rejected snapshot changed the journal to %q", j.String())
+	}
+
+	lowLastID := &journalDocument{1, []Entry{{ID: 4, Text: "a"}}}
+	if err := j.apply(logRecord{Op: logSnapshot, Snapshot: lowLastID}); err != nil {
+		t.Fatal(err)
+	}
+	if id := j.AddEntry("next"); id != 5 {
+		t.Errorf("got ID %d for the next entry, want 5", id)
+	}
+}
@@ -0,0 +1,113 @@
+package solid
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Reading a journal back is the Persistence's concern too, the Journal itself still knows nothing about files
+
+var (
+	ErrMalformedEntry   = errors.New("malformed entry")
+	ErrDuplicateEntryID = errors.New("duplicate entry id")
+)
+
+// ParseError tells which line of the file we couldn't make sense of
+type ParseError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v: %q", e.Line, e.Err, e.Text)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseJournal reads "N: text" lines separated by either "\n" or "\r\n"
+func ParseJournal(r io.Reader) (*Journal, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJournalLines(splitNewlines(string(data)))
+}
+
+// Files written with "\n" or "\r\n" are both accepted, whichever separator this Persistence writes
+// Any other separator has to match exactly, since we can't guess it
+func (p *Persistence) parse(data []byte) (*Journal, error) {
+	if p.lineSeparator == "\n" || p.lineSeparator == "\r\n" {
+		return parseJournalLines(splitNewlines(string(data)))
+	}
+
+	lines := []string{}
+	if len(data) > 0 {
+		lines = strings.Split(string(data), p.lineSeparator)
+	}
+
+	return parseJournalLines(lines)
+}
+
+func splitNewlines(data string) []string {
+	if data == "" {
+		return []string{}
+	}
+
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	// SaveToFile never writes a trailing separator, but an editor might
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func parseJournalLines(lines []string) (*Journal, error) {
+	j := &Journal{}
+	seen := map[int]bool{}
+
+	for i, line := range lines {
+		e, err := parseEntryLine(line)
+		if err == nil && seen[e.ID] {
+			err = ErrDuplicateEntryID
+		}
+		if err != nil {
+			return nil, &ParseError{Line: i + 1, Text: line, Err: err}
+		}
+
+		seen[e.ID] = true
+		j.entries = append(j.entries, e)
+		if e.ID > j.lastID {
+			j.lastID = e.ID
+		}
+	}
+
+	return j, nil
+}
+
+// parseEntryLine reads back the "N: text" format written by Entry.String()
+// The ID has to be written exactly like Entry.String() writes it, so "01: text" is rejected
+func parseEntryLine(line string) (Entry, error) {
+	idText, text, ok := strings.Cut(line, ": ")
+	if !ok {
+		return Entry{}, ErrMalformedEntry
+	}
+
+	id, err := strconv.Atoi(idText)
+	if err != nil || id <= 0 || strconv.Itoa(id) != idText {
+		return Entry{}, fmt.Errorf("%w: bad id %q", ErrMalformedEntry, idText)
+	}
+
+	return Entry{ID: id, Text: text}, nil
+}
@@ -0,0 +1,115 @@
+package solid
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Querying a journal is the same problem the BetterFilter solves for products in ocp.go
+// So instead of adding FilterByTag, FilterByDate, FilterByTagAndDate... to the Journal,
+// we reuse the Specification idea: each criterion is a small type, and they can be combined freely
+type EntrySpecification interface {
+	IsSatisfied(e *Entry) bool
+}
+
+// DateRangeSpecification matches entries created in [from, to)
+// A zero from or to leaves that side of the range open
+type DateRangeSpecification struct {
+	from, to time.Time
+}
+
+func NewDateRangeSpecification(from, to time.Time) DateRangeSpecification {
+	return DateRangeSpecification{from, to}
+}
+
+func (d DateRangeSpecification) IsSatisfied(e *Entry) bool {
+	if !d.from.IsZero() && e.Created.Before(d.from) {
+		return false
+	}
+
+	return d.to.IsZero() || e.Created.Before(d.to)
+}
+
+type TagSpecification struct {
+	tag string
+}
+
+func NewTagSpecification(tag string) TagSpecification {
+	return TagSpecification{tag}
+}
+
+func (t TagSpecification) IsSatisfied(e *Entry) bool {
+	return e.HasTag(t.tag)
+}
+
+// TextSpecification matches entries containing the given text, ignoring case
+type TextSpecification struct {
+	text string
+}
+
+func NewTextSpecification(text string) TextSpecification {
+	return TextSpecification{strings.ToLower(text)}
+}
+
+func (t TextSpecification) IsSatisfied(e *Entry) bool {
+	return strings.Contains(strings.ToLower(e.Text), t.text)
+}
+
+type AuthorSpecification struct {
+	author string
+}
+
+func NewAuthorSpecification(author string) AuthorSpecification {
+	return AuthorSpecification{author}
+}
+
+func (a AuthorSpecification) IsSatisfied(e *Entry) bool {
+	return e.Author == a.author
+}
+
+// Just like the AndSpecification for products, these let us compose any entry specifications
+type AndEntrySpecification struct {
+	first, second EntrySpecification
+}
+
+func NewAndEntrySpecification(first, second EntrySpecification) AndEntrySpecification {
+	return AndEntrySpecification{first, second}
+}
+
+func (as AndEntrySpecification) IsSatisfied(e *Entry) bool {
+	return as.first.IsSatisfied(e) && as.second.IsSatisfied(e)
+}
+
+type OrEntrySpecification struct {
+	first, second EntrySpecification
+}
+
+func NewOrEntrySpecification(first, second EntrySpecification) OrEntrySpecification {
+	return OrEntrySpecification{first, second}
+}
+
+func (os OrEntrySpecification) IsSatisfied(e *Entry) bool {
+	return os.first.IsSatisfied(e) || os.second.IsSatisfied(e)
+}
+
+// Query returns copies of the entries satisfying the specification, oldest first
+// Entries created at the same instant keep their ID order
+func (j *Journal) Query(spec EntrySpecification) []Entry {
+	result := []Entry{}
+	for i := range j.entries {
+		if spec == nil || spec.IsSatisfied(&j.entries[i]) {
+			result = append(result, j.entries[i].clone())
+		}
+	}
+
+	sort.SliceStable(result, func(a, b int) bool {
+		if !result[a].Created.Equal(result[b].Created) {
+			return result[a].Created.Before(result[b].Created)
+		}
+
+		return result[a].ID < result[b].ID
+	})
+
+	return result
+}
@@ -0,0 +1,265 @@
+package solid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The Persistence struct in srp.go only knows how to write a journal to a flat file
+// A JournalStore takes the idea further: whoever needs to persist journals depends on this abstraction
+// and we can swap plain-text files, JSON files or memory without touching the callers
+type JournalStore interface {
+	Save(name string, j *Journal) error
+	Load(name string) (*Journal, error)
+	List() ([]string, error)
+	Delete(name string) error
+}
+
+var (
+	ErrJournalNotFound    = errors.New("journal not found")
+	ErrInvalidJournalName = errors.New("invalid journal name")
+)
+
+// Journal names become file names, so we don't let them escape the store's directory
+func validateJournalName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidJournalName, name)
+	}
+
+	return nil
+}
+
+// Both file stores keep one file per journal inside a directory, they only differ in the file format
+type fileStore struct {
+	dir       string
+	extension string
+}
+
+func (fs fileStore) path(name string) (string, error) {
+	if err := validateJournalName(name); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(fs.dir, name+fs.extension), nil
+}
+
+func (fs fileStore) read(name string) ([]byte, error) {
+	path, err := fs.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrJournalNotFound, name)
+	}
+
+	return data, err
+}
+
+func (fs fileStore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(fs.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fs.extension) {
+			continue
+		}
+		result = append(result, strings.TrimSuffix(f.Name(), fs.extension))
+	}
+
+	return result, nil
+}
+
+func (fs fileStore) Delete(name string) error {
+	path, err := fs.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %q", ErrJournalNotFound, name)
+	}
+
+	return err
+}
+
+// TextFileStore writes journals in the same format as journal.txt, reusing our Persistence struct
+// That format only has room for IDs and text, use the JSONFileStore to keep timestamps, tags and authors
+type TextFileStore struct {
+	fileStore
+	persistence Persistence
+}
+
+func NewTextFileStore(dir, lineSeparator string) (*TextFileStore, error) {
+	persistence, err := NewPersistence(lineSeparator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TextFileStore{
+		fileStore:   fileStore{dir, ".txt"},
+		persistence: *persistence,
+	}, nil
+}
+
+func (ts *TextFileStore) Save(name string, j *Journal) error {
+	path, err := ts.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ts.dir, 0755); err != nil {
+		return err
+	}
+
+	return ts.persistence.SaveToFile(j, path)
+}
+
+func (ts *TextFileStore) Load(name string) (*Journal, error) {
+	data, err := ts.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.persistence.parse(data)
+}
+
+// JSONFileStore keeps each journal as a JSON document
+type JSONFileStore struct {
+	fileStore
+}
+
+// We keep the last ID too, otherwise IDs of removed entries would be handed out again after loading
+type journalDocument struct {
+	LastID  int     `json:"lastId"`
+	Entries []Entry `json:"entries"`
+}
+
+// journal checks the entries the same way as a text file's, a file edited by hand could have duplicate IDs
+// or a last ID below some of them, and new entries would then reuse IDs that are still there
+func (doc journalDocument) journal() (*Journal, error) {
+	j := &Journal{}
+	for _, e := range doc.Entries {
+		if err := j.restoreEntry(e); err != nil {
+			return nil, err
+		}
+	}
+	if doc.LastID > j.lastID {
+		j.lastID = doc.LastID
+	}
+
+	return j, nil
+}
+
+func NewJSONFileStore(dir string) *JSONFileStore {
+	return &JSONFileStore{fileStore{dir, ".json"}}
+}
+
+func (js *JSONFileStore) Save(name string, j *Journal) error {
+	path, err := js.path(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(journalDocument{j.lastID, j.Entries()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(js.dir, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (js *JSONFileStore) Load(name string) (*Journal, error) {
+	data, err := js.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := journalDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decoding journal %q: %w", name, err)
+	}
+	j, err := doc.journal()
+	if err != nil {
+		return nil, fmt.Errorf("decoding journal %q: %w", name, err)
+	}
+
+	return j, nil
+}
+
+// MemoryStore is handy for tests: nothing touches the disk
+// It keeps copies, so changing a journal after saving it doesn't change what's stored
+type MemoryStore struct {
+	mu       sync.RWMutex
+	journals map[string]*Journal
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{journals: map[string]*Journal{}}
+}
+
+func (ms *MemoryStore) Save(name string, j *Journal) error {
+	if err := validateJournalName(name); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.journals[name] = j.clone()
+
+	return nil
+}
+
+func (ms *MemoryStore) Load(name string) (*Journal, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	j, ok := ms.journals[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrJournalNotFound, name)
+	}
+
+	return j.clone(), nil
+}
+
+func (ms *MemoryStore) List() ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	result := make([]string, 0, len(ms.journals))
+	for name := range ms.journals {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+func (ms *MemoryStore) Delete(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.journals[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrJournalNotFound, name)
+	}
+	delete(ms.journals, name)
+
+	return nil
+}
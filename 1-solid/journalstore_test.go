@@ -0,0 +1,38 @@
+package solid
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONFileStoreChecksLoadedEntries(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       error
+		lastID     int
+	}{
+		{"duplicate ids", `{"lastId": 2, "entries": [{"id": 1, "text": "a"}, {"id": 1, "text": "b"}]}`, ErrDuplicateEntryID, 0},
+		{"invalid id", `{"lastId": 2, "entries": [{"id": 0, "text": "a"}]}`, ErrMalformedEntry, 0},
+		{"last id below the entries", `{"lastId": 1, "entries": [{"id": 3, "text": "a"}]}`, nil, 3},
+		{"last id of removed entries", `{"lastId": 5, "entries": [{"id": 3, "text": "a"}]}`, nil, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := ioutil.WriteFile(filepath.Join(dir, "daily.json"), []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			j, err := NewJSONFileStore(dir).Load("daily")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if err == nil && j.lastID != tt.lastID {
+				t.Errorf("got last ID %d, want %d", j.lastID, tt.lastID)
+			}
+		})
+	}
+}
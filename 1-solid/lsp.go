@@ -1,6 +1,11 @@
 package solid
 
-import "fmt"
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pedr0diniz/1-solid/shape"
+)
 
 // LSP: Liskov Substitutive Principle
 // Doesn't really apply to Go, as there is no inheritance here
@@ -108,4 +113,47 @@ func Lsp() {
 	sq2 := Square2{5}
 	sq2Rec := sq2.Rectangle()
 	UseIt(&sq2Rec)
+
+	// UseIt only tries one case, the contract checker tries every law callers rely on with random sizes
+	r := rand.New(rand.NewSource(1))
+	implementations := []struct {
+		name     string
+		newSized func() Sized
+	}{
+		{"Rectangle", func() Sized { return &Rectangle{} }},
+		{"Square", func() Sized { return NewSquare(0) }},
+	}
+	for _, impl := range implementations {
+		violations := CheckSizedContract(impl.newSized, r, 100)
+		fmt.Printf("%s breaks %d law(s) of the Sized contract\n", impl.name, len(violations))
+		for _, v := range violations {
+			fmt.Println(" -", v)
+		}
+	}
+
+	// The shape package goes the other way: shapes can't be changed, only copied with different sizes
+	// So asking a square for a different width gives us a rectangle, and the square stays a square
+	square := shape.NewSquare(shape.Point{}, 5)
+	wider := square.WithWidth(10)
+	fmt.Printf("%v is still a square next to %v\n", square, wider)
+
+	// And every shape can be used wherever a Shape is expected
+	shapes := []shape.Shape{
+		square,
+		wider,
+		shape.NewCircle(shape.Point{X: 1, Y: 1}, 1).Scale(2),
+		shape.NewTriangle(shape.Point{}, shape.Point{X: 4}, shape.Point{Y: 3}),
+		shape.NewPolygon(shape.Point{}, shape.Point{X: 2}, shape.Point{X: 2, Y: 2}, shape.Point{X: 1, Y: 3}, shape.Point{Y: 2}),
+	}
+	for _, s := range shapes {
+		fmt.Printf(" - %v: area %.2f, perimeter %.2f, bounds %v\n", s, s.Area(), s.Perimeter(), s.Bounds())
+	}
+	fmt.Printf("All of them fit in %v\n", shape.UnionBounds(shapes...))
+
+	// Shapes can answer geometric questions too, edges count as part of the shape
+	unit := shape.NewRectangle(shape.Point{}, 1, 1)
+	neighbour := unit.Translate(1, 0)
+	if overlap, ok := unit.Intersection(neighbour); ok {
+		fmt.Printf("%v and %v touch along %v\n", unit, neighbour, overlap)
+	}
 }
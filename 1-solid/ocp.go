@@ -1,6 +1,10 @@
 package solid
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/pedr0diniz/1-solid/spec"
+)
 
 // OCP: Open-Closed Principle
 // Open for Extension, Closed for Modification
@@ -8,9 +12,12 @@ import "fmt"
 // We'll also learn an Enterprise Pattern called "Specification" here.
 
 type Product struct {
-	name  string
-	color Color
-	size  Size
+	name   string
+	color  Color
+	size   Size
+	price  float64
+	weight float64
+	tags   []string
 }
 
 type Color int
@@ -112,8 +119,9 @@ type BetterFilter struct{}
 
 func (bf *BetterFilter) Filter(products []Product, spec Specification) []*Product {
 	result := []*Product{}
-	for i, v := range products {
-		if spec.IsSatisfied(&v) {
+	// Specifications get a pointer into the slice, not to a copy that changes on every iteration
+	for i := range products {
+		if spec.IsSatisfied(&products[i]) {
 			result = append(result, &products[i])
 		}
 	}
@@ -122,9 +130,9 @@ func (bf *BetterFilter) Filter(products []Product, spec Specification) []*Produc
 }
 
 func Ocp() {
-	apple := Product{"Apple", green, small}
-	tree := Product{"Tree", green, large}
-	house := Product{"House", blue, large}
+	apple := NewProduct("Apple", green, small, WithPrice(0.5), WithWeight(0.2), WithProductTags("fruit"))
+	tree := NewProduct("Tree", green, large, WithPrice(120), WithWeight(500), WithProductTags("garden"))
+	house := NewProduct("House", blue, large, WithPrice(250000), WithWeight(80000))
 
 	products := []Product{apple, tree, house}
 
@@ -163,6 +171,77 @@ func Ocp() {
 	for _, v := range bf.Filter(products, largeGreenSpec) {
 		fmt.Printf(" - %s is large and green\n", v.name)
 	}
+
+	// More combinators can be added the same way, and chained fluently
+	greenOrBlueNotLarge := Spec(greenSpec).Or(ColorSpecification{blue}).And(Spec(largeSpec).Not())
+	fmt.Printf("\nGreen or blue products that aren't large (new): \n")
+	for _, v := range bf.Filter(products, greenOrBlueNotLarge) {
+		fmt.Printf(" - %s is green or blue and not large\n", v.name)
+	}
+
+	// Users don't have to build these trees by hand, they can write a query instead
+	query := `color = green AND (size = large OR name ~ "A*")`
+	querySpec, err := ParseSpecification(query)
+	if err != nil {
+		fmt.Println("Could not parse the query:", err)
+		return
+	}
+	fmt.Printf("\nProducts matching %s: \n", querySpec)
+	for _, v := range bf.Filter(products, querySpec) {
+		fmt.Printf(" - %s\n", v.name)
+	}
+
+	// The generic Filter works for any type, and our product specifications plug right into it
+	smallSpec := spec.Func[*Product](func(p *Product) bool { return p.size == small })
+	gf := spec.Filter[Product]{}
+	fmt.Printf("\nGreen or small products (generic): \n")
+	for _, v := range gf.FilterRefs(products, spec.Or[*Product](greenSpec, smallSpec)) {
+		fmt.Printf(" - %s\n", v.name)
+	}
+
+	// Products now have prices, weights and tags, with specifications of their own
+	affordableFruit := Spec(NewPriceRangeSpecification(0, 10)).And(NewProductTagSpecification("fruit"))
+	fmt.Printf("\nProducts matching %s: \n", affordableFruit)
+	for _, v := range bf.Filter(products, affordableFruit) {
+		fmt.Printf(" - %s costs %.2f\n", v.name, v.price)
+	}
+
+	// And when a product doesn't make it through, we can see which part of the specification rejected it
+	fmt.Printf("\nWhy the Tree isn't an affordable fruit: \n%s\n", Evaluate(affordableFruit, &tree))
+
+	// Combining specifications tends to repeat things, Normalize cleans them up without changing what they match
+	messy := Spec(greenSpec).And(Spec(greenSpec).And(Spec(largeSpec).Not().Not())).Or(AllOf(ColorSpecification{red}, greenSpec))
+	fmt.Printf("\n%s is the same as %s\n", messy, Normalize(messy))
+
+	// Catalog views want the matches sorted and split in pages, with a cursor to get the next one
+	q := ProductQuery{Spec: Spec(largeSpec).Not().Or(greenSpec), OrderBy: []SortKey{Desc(FieldPrice), Asc(FieldName)}, Limit: 1}
+	for {
+		page, err := bf.Query(products, q)
+		if err != nil {
+			fmt.Println("Could not query the products:", err)
+			break
+		}
+		fmt.Printf("\nPage of %d product(s) by price, out of %d: \n", len(page.Products), page.Total)
+		for _, v := range page.Products {
+			fmt.Printf(" - %s costs %.2f\n", v.name, v.price)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.After = page.NextCursor
+	}
+
+	// Specifications can be saved as JSON and loaded back, e.g. for saved searches
+	if saved, err := MarshalSpecification(querySpec); err == nil {
+		fmt.Printf("\nSaved search: %s\n", saved)
+	}
+
+	// A Catalog answers color and size specifications from its indexes instead of checking every product
+	catalog := NewCatalog(products...)
+	fmt.Printf("\nHow the catalog answers %s: \n%s\n", querySpec, catalog.Explain(querySpec))
+	for _, v := range catalog.Query(querySpec) {
+		fmt.Printf(" - %s\n", v.name)
+	}
 }
 
 // The idea here is to not keep jumping to the same file and keep modifying it over and over again
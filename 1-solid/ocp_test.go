@@ -0,0 +1,33 @@
+package solid
+
+import "testing"
+
+// keepingSpecification remembers every product it was asked about
+type keepingSpecification struct {
+	seen *[]*Product
+}
+
+func (ks keepingSpecification) IsSatisfied(p *Product) bool {
+	*ks.seen = append(*ks.seen, p)
+	return true
+}
+
+func TestFilterPassesPointersIntoTheSlice(t *testing.T) {
+	products := []Product{
+		NewProduct("Apple", green, small),
+		NewProduct("Tree", green, large),
+		NewProduct("House", blue, large),
+	}
+	seen := []*Product{}
+
+	result := (&BetterFilter{}).Filter(products, keepingSpecification{&seen})
+
+	for i := range products {
+		if seen[i] != &products[i] {
+			t.Errorf("the specification got %p for product %d, want %p", seen[i], i, &products[i])
+		}
+		if result[i] != &products[i] {
+			t.Errorf("the result has %p for product %d, want %p", result[i], i, &products[i])
+		}
+	}
+}
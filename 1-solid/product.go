@@ -0,0 +1,239 @@
+package solid
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Products used to be just a name, a color and a size
+// Now they also have a price, a weight and tags, which are optional, so they're set with functional options
+
+type ProductOption func(*Product)
+
+func NewProduct(name string, color Color, size Size, options ...ProductOption) Product {
+	p := Product{name: name, color: color, size: size}
+	for _, option := range options {
+		option(&p)
+	}
+
+	return p
+}
+
+func WithPrice(price float64) ProductOption {
+	return func(p *Product) {
+		p.price = price
+	}
+}
+
+func WithWeight(weight float64) ProductOption {
+	return func(p *Product) {
+		p.weight = weight
+	}
+}
+
+func WithProductTags(tags ...string) ProductOption {
+	return func(p *Product) {
+		for _, t := range tags {
+			if !p.HasTag(t) {
+				p.tags = append(p.tags, t)
+			}
+		}
+	}
+}
+
+func (p *Product) Name() string    { return p.name }
+func (p *Product) Color() Color    { return p.color }
+func (p *Product) Size() Size      { return p.size }
+func (p *Product) Price() float64  { return p.price }
+func (p *Product) Weight() float64 { return p.weight }
+func (p *Product) Tags() []string  { return append([]string{}, p.tags...) }
+
+func (p *Product) HasTag(tag string) bool {
+	for _, t := range p.tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Colors and sizes print as their names and parse back from them, ignoring case
+// They implement encoding.TextMarshaler too, so they show up as names in JSON
+
+var colorNames = map[Color]string{red: "red", green: "green", blue: "blue"}
+
+var sizeNames = map[Size]string{small: "small", medium: "medium", large: "large"}
+
+func (c Color) String() string {
+	if name, ok := colorNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Color(%d)", int(c))
+}
+
+func ParseColor(s string) (Color, error) {
+	if c, ok := lookupName(colorNames, s); ok {
+		return c, nil
+	}
+
+	return 0, fmt.Errorf("unknown color %q", s)
+}
+
+func (c Color) MarshalText() ([]byte, error) {
+	if _, ok := colorNames[c]; !ok {
+		return nil, fmt.Errorf("unknown color %d", int(c))
+	}
+
+	return []byte(c.String()), nil
+}
+
+func (c *Color) UnmarshalText(text []byte) error {
+	parsed, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+
+	return nil
+}
+
+func (s Size) String() string {
+	if name, ok := sizeNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Size(%d)", int(s))
+}
+
+func ParseSize(s string) (Size, error) {
+	if size, ok := lookupName(sizeNames, s); ok {
+		return size, nil
+	}
+
+	return 0, fmt.Errorf("unknown size %q", s)
+}
+
+func (s Size) MarshalText() ([]byte, error) {
+	if _, ok := sizeNames[s]; !ok {
+		return nil, fmt.Errorf("unknown size %d", int(s))
+	}
+
+	return []byte(s.String()), nil
+}
+
+func (s *Size) UnmarshalText(text []byte) error {
+	parsed, err := ParseSize(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+
+	return nil
+}
+
+func lookupName[T comparable](names map[T]string, name string) (T, bool) {
+	for value, n := range names {
+		if strings.EqualFold(n, name) {
+			return value, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// The new attributes get their own specifications, again without touching the existing ones
+
+// PriceRangeSpecification matches prices in [min, max], use math.Inf for an open side
+type PriceRangeSpecification struct {
+	min, max float64
+}
+
+func NewPriceRangeSpecification(min, max float64) PriceRangeSpecification {
+	return PriceRangeSpecification{min, max}
+}
+
+func (pr PriceRangeSpecification) IsSatisfied(p *Product) bool {
+	return p.price >= pr.min && p.price <= pr.max
+}
+
+// WeightRangeSpecification matches weights in [min, max], use math.Inf for an open side
+type WeightRangeSpecification struct {
+	min, max float64
+}
+
+func NewWeightRangeSpecification(min, max float64) WeightRangeSpecification {
+	return WeightRangeSpecification{min, max}
+}
+
+func (wr WeightRangeSpecification) IsSatisfied(p *Product) bool {
+	return p.weight >= wr.min && p.weight <= wr.max
+}
+
+// NameContainsSpecification matches names containing the text, ignoring case
+type NameContainsSpecification struct {
+	text string
+}
+
+func NewNameContainsSpecification(text string) NameContainsSpecification {
+	return NameContainsSpecification{text}
+}
+
+func (nc NameContainsSpecification) IsSatisfied(p *Product) bool {
+	return strings.Contains(strings.ToLower(p.name), strings.ToLower(nc.text))
+}
+
+type NameRegexpSpecification struct {
+	re *regexp.Regexp
+}
+
+func NewNameRegexpSpecification(expr string) (NameRegexpSpecification, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return NameRegexpSpecification{}, err
+	}
+
+	return NameRegexpSpecification{re}, nil
+}
+
+func (nr NameRegexpSpecification) IsSatisfied(p *Product) bool {
+	return nr.re.MatchString(p.name)
+}
+
+// ProductTagSpecification matches products with the tag
+type ProductTagSpecification struct {
+	tag string
+}
+
+func NewProductTagSpecification(tag string) ProductTagSpecification {
+	return ProductTagSpecification{tag}
+}
+
+func (pt ProductTagSpecification) IsSatisfied(p *Product) bool {
+	return p.HasTag(pt.tag)
+}
+
+// openRange turns a comparison into the [min, max] bounds used by the range specifications
+// Strict comparisons move the bound to the next representable float, so the range stays closed
+func openRange(op string, value float64) (min, max float64) {
+	min, max = math.Inf(-1), math.Inf(1)
+
+	switch op {
+	case "=":
+		min, max = value, value
+	case "<":
+		max = math.Nextafter(value, math.Inf(-1))
+	case "<=":
+		max = value
+	case ">":
+		min = math.Nextafter(value, math.Inf(1))
+	case ">=":
+		min = value
+	}
+
+	return min, max
+}
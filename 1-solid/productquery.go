@@ -0,0 +1,285 @@
+package solid
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Filter gives us every matching product, in whatever order they came in
+// A ProductQuery adds what a catalog view needs on top of the specification: ordering, pages and projection
+
+// ProductField names the product fields a query can sort by and select
+type ProductField int
+
+const (
+	FieldName ProductField = iota
+	FieldColor
+	FieldSize
+	FieldPrice
+	FieldWeight
+)
+
+var productFieldNames = map[ProductField]string{
+	FieldName:   "name",
+	FieldColor:  "color",
+	FieldSize:   "size",
+	FieldPrice:  "price",
+	FieldWeight: "weight",
+}
+
+func (pf ProductField) String() string {
+	if name, ok := productFieldNames[pf]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ProductField(%d)", int(pf))
+}
+
+func (pf ProductField) validate() error {
+	if _, ok := productFieldNames[pf]; !ok {
+		return fmt.Errorf("%w: %v", ErrInvalidField, pf)
+	}
+
+	return nil
+}
+
+func (pf ProductField) value(p *Product) any {
+	switch pf {
+	case FieldName:
+		return p.name
+	case FieldColor:
+		return p.color
+	case FieldSize:
+		return p.size
+	case FieldPrice:
+		return p.price
+	case FieldWeight:
+		return p.weight
+	}
+
+	return nil
+}
+
+type SortKey struct {
+	Field      ProductField
+	Descending bool
+}
+
+func Asc(field ProductField) SortKey  { return SortKey{field, false} }
+func Desc(field ProductField) SortKey { return SortKey{field, true} }
+
+func (sk SortKey) String() string {
+	if sk.Descending {
+		return "-" + sk.Field.String()
+	}
+
+	return sk.Field.String()
+}
+
+// ProductQuery picks a page of the products satisfying Spec, nil meaning every product
+// Products are sorted by the keys in OrderBy, and keep their original order when the keys tie
+// Limit 0 means no limit
+// After continues from the NextCursor of a previous page, and Offset skips products after that
+// Select picks the fields that go in the page's Rows, which are left out when it's empty
+type ProductQuery struct {
+	Spec    Specification
+	OrderBy []SortKey
+	Offset  int
+	Limit   int
+	After   string
+	Select  []ProductField
+}
+
+type ProductPage struct {
+	Products []*Product
+	// Rows has the selected fields of each product, by field name, ready to be encoded for an API
+	Rows []map[string]any
+	// Total is how many products satisfy the specification, on every page
+	Total int
+	// NextCursor is empty on the last page
+	NextCursor string
+}
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidPage   = errors.New("invalid page")
+	ErrInvalidField  = errors.New("invalid product field")
+)
+
+// The cursor remembers where the last page ended, as the sort values of its last product
+// Unlike an offset, it keeps pointing at the same place when products are added before it,
+// except for products tying with it on every sort key: ties are broken by position in the slice, and that shifts
+// It's base64 so clients treat it as opaque, and it only works with the ordering it was made for
+type productCursor struct {
+	Order    string  `json:"order"`
+	Name     string  `json:"name"`
+	Color    Color   `json:"color"`
+	Size     Size    `json:"size"`
+	Price    float64 `json:"price"`
+	Weight   float64 `json:"weight"`
+	Position int     `json:"position"`
+}
+
+func (bf *BetterFilter) Query(products []Product, q ProductQuery) (*ProductPage, error) {
+	if q.Offset < 0 || q.Limit < 0 {
+		return nil, fmt.Errorf("%w: offset %d and limit %d can't be negative", ErrInvalidPage, q.Offset, q.Limit)
+	}
+	// An unknown field would tie on every product when sorting, and come out as an empty column
+	for _, k := range q.OrderBy {
+		if err := k.Field.validate(); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range q.Select {
+		if err := f.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	order := formatOrder(q.OrderBy)
+	var after *productCursor
+	if q.After != "" {
+		c, err := decodeCursor(q.After)
+		if err != nil {
+			return nil, err
+		}
+		if c.Order != order {
+			return nil, fmt.Errorf("%w: it was made for order %q, not %q", ErrInvalidCursor, c.Order, order)
+		}
+		after = c
+	}
+
+	matches := []productCursor{}
+	for i := range products {
+		if q.Spec == nil || q.Spec.IsSatisfied(&products[i]) {
+			matches = append(matches, cursorFor(&products[i], i, order))
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		return compareCursors(&matches[a], &matches[b], q.OrderBy) < 0
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return compareCursors(&matches[i], after, q.OrderBy) > 0
+		})
+	}
+	start += q.Offset
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := len(matches)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+	}
+
+	page := &ProductPage{Products: []*Product{}, Total: len(matches)}
+	for _, m := range matches[start:end] {
+		page.Products = append(page.Products, &products[m.Position])
+	}
+	if len(q.Select) > 0 {
+		page.Rows = project(page.Products, q.Select)
+	}
+	if end < len(matches) && end > start {
+		cursor, err := encodeCursor(matches[end-1])
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = cursor
+	}
+
+	return page, nil
+}
+
+func formatOrder(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.String()
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func cursorFor(p *Product, position int, order string) productCursor {
+	return productCursor{order, p.name, p.color, p.size, p.price, p.weight, position}
+}
+
+// compareCursors orders by the sort keys, and then by position in the products, so no two products tie
+func compareCursors(a, b *productCursor, keys []SortKey) int {
+	for _, k := range keys {
+		c := 0
+		switch k.Field {
+		case FieldName:
+			c = strings.Compare(a.Name, b.Name)
+		case FieldColor:
+			c = compareOrdered(a.Color, b.Color)
+		case FieldSize:
+			c = compareOrdered(a.Size, b.Size)
+		case FieldPrice:
+			c = compareOrdered(a.Price, b.Price)
+		case FieldWeight:
+			c = compareOrdered(a.Weight, b.Weight)
+		}
+		if k.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareOrdered(a.Position, b.Position)
+}
+
+func compareOrdered[T ~int | ~float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func project(products []*Product, fields []ProductField) []map[string]any {
+	rows := make([]map[string]any, len(products))
+	for i, p := range products {
+		row := map[string]any{}
+		for _, f := range fields {
+			row[f.String()] = f.value(p)
+		}
+		rows[i] = row
+	}
+
+	return rows
+}
+
+// Products with an unknown color or size, or a NaN or infinite price or weight, can't be encoded in a cursor
+func encodeCursor(c productCursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (*productCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	c := &productCursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,82 @@
+package solid
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueryPagesWithCursor(t *testing.T) {
+	products := []Product{
+		NewProduct("Apple", green, small, WithPrice(0.5)),
+		NewProduct("Tree", green, large, WithPrice(120)),
+		NewProduct("House", blue, large, WithPrice(250000)),
+		NewProduct("Pear", green, small, WithPrice(0.5)),
+		NewProduct("Bush", red, medium, WithPrice(30)),
+	}
+	bf := BetterFilter{}
+	order := []SortKey{Asc(FieldPrice), Desc(FieldName)}
+
+	all, err := bf.Query(products, ProductQuery{OrderBy: order})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []*Product{}
+	q := ProductQuery{OrderBy: order, Limit: 2}
+	for {
+		page, err := bf.Query(products, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != len(products) {
+			t.Errorf("got a total of %d, want %d", page.Total, len(products))
+		}
+		got = append(got, page.Products...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.After = page.NextCursor
+	}
+
+	if !reflect.DeepEqual(got, all.Products) {
+		t.Errorf("paging gave %d products, want the same %d as a single query", len(got), len(all.Products))
+	}
+	if names := []string{got[0].name, got[1].name, got[4].name}; !reflect.DeepEqual(names, []string{"Pear", "Apple", "House"}) {
+		t.Errorf("got %v in the wrong order", names)
+	}
+}
+
+func TestQueryReportsUnencodableCursor(t *testing.T) {
+	products := []Product{NewProduct("Odd", Color(7), small), NewProduct("Apple", green, small)}
+
+	if _, err := (&BetterFilter{}).Query(products, ProductQuery{Limit: 1}); err == nil {
+		t.Error("expected an error for a cursor with an unknown color")
+	}
+}
+
+func TestQuerySelectsFields(t *testing.T) {
+	products := []Product{NewProduct("Apple", green, small, WithPrice(0.5))}
+
+	page, err := (&BetterFilter{}).Query(products, ProductQuery{Select: []ProductField{FieldName, FieldPrice}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{{"name": "Apple", "price": 0.5}}
+	if !reflect.DeepEqual(page.Rows, want) {
+		t.Errorf("got %v, want %v", page.Rows, want)
+	}
+}
+
+func TestQueryRejectsUnknownFields(t *testing.T) {
+	products := []Product{NewProduct("Apple", green, small)}
+
+	for _, q := range []ProductQuery{
+		{OrderBy: []SortKey{Asc(FieldName), Desc(ProductField(9))}},
+		{Select: []ProductField{FieldName, ProductField(9)}},
+	} {
+		if _, err := (&BetterFilter{}).Query(products, q); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("%+v: got %v, want %v", q, err, ErrInvalidField)
+		}
+	}
+}
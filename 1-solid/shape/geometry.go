@@ -0,0 +1,137 @@
+package shape
+
+import "math"
+
+// Shapes are closed: their edges belong to them
+// So shapes that only touch each other intersect, and shapes with a zero side still contain the points on them
+
+func (p Point) Translate(dx, dy float64) Point {
+	return Point{p.X + dx, p.Y + dy}
+}
+
+func (b Bounds) Contains(p Point) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X && p.Y >= b.Min.Y && p.Y <= b.Max.Y
+}
+
+func (b Bounds) Intersects(other Bounds) bool {
+	return b.Min.X <= other.Max.X && other.Min.X <= b.Max.X && b.Min.Y <= other.Max.Y && other.Min.Y <= b.Max.Y
+}
+
+// Union is the smallest box around both bounds
+func (b Bounds) Union(other Bounds) Bounds {
+	return Bounds{
+		Point{math.Min(b.Min.X, other.Min.X), math.Min(b.Min.Y, other.Min.Y)},
+		Point{math.Max(b.Max.X, other.Max.X), math.Max(b.Max.Y, other.Max.Y)},
+	}
+}
+
+// UnionBounds is the smallest box around all of the shapes, and empty bounds at the origin when there are none
+func UnionBounds(shapes ...Shape) Bounds {
+	if len(shapes) == 0 {
+		return Bounds{}
+	}
+
+	b := shapes[0].Bounds()
+	for _, s := range shapes[1:] {
+		b = b.Union(s.Bounds())
+	}
+
+	return b
+}
+
+func (r Rectangle) Contains(p Point) bool { return r.Bounds().Contains(p) }
+func (s Square) Contains(p Point) bool    { return s.Bounds().Contains(p) }
+
+func (c Circle) Contains(p Point) bool {
+	return c.center.distance(p) <= c.radius
+}
+
+func (t Triangle) Contains(p Point) bool {
+	return polygonContains([]Point{t.a, t.b, t.c}, p)
+}
+
+func (p Polygon) Contains(point Point) bool {
+	return polygonContains(p.points, point)
+}
+
+// polygonContains checks the edges first, and then casts a ray to the right of the point:
+// it crosses the edges an odd number of times only when the point is inside
+func polygonContains(points []Point, p Point) bool {
+	for i, a := range points {
+		if onSegment(a, points[(i+1)%len(points)], p) {
+			return true
+		}
+	}
+
+	inside := false
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func onSegment(a, b, p Point) bool {
+	cross := (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+	return cross == 0 && boundsOf(a, b).Contains(p)
+}
+
+// Intersects tells whether the rectangles share at least one point
+func (r Rectangle) Intersects(other Rectangle) bool {
+	return r.Bounds().Intersects(other.Bounds())
+}
+
+// Intersection returns the rectangle both rectangles cover, which has a zero side when they only touch
+func (r Rectangle) Intersection(other Rectangle) (Rectangle, bool) {
+	if !r.Intersects(other) {
+		return Rectangle{}, false
+	}
+
+	a, b := r.Bounds(), other.Bounds()
+	min := Point{math.Max(a.Min.X, b.Min.X), math.Max(a.Min.Y, b.Min.Y)}
+	max := Point{math.Min(a.Max.X, b.Max.X), math.Min(a.Max.Y, b.Max.Y)}
+
+	return NewRectangle(min, max.X-min.X, max.Y-min.Y), true
+}
+
+// IntersectsRectangle checks the point of the rectangle closest to the center
+func (c Circle) IntersectsRectangle(r Rectangle) bool {
+	b := r.Bounds()
+	closest := Point{clamp(c.center.X, b.Min.X, b.Max.X), clamp(c.center.Y, b.Min.Y, b.Max.Y)}
+
+	return c.Contains(closest)
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(v, max))
+}
+
+// Translate moves a shape without changing its size, into a new shape like everything else
+
+func (r Rectangle) Translate(dx, dy float64) Rectangle {
+	return Rectangle{r.origin.Translate(dx, dy), r.width, r.height}
+}
+
+func (s Square) Translate(dx, dy float64) Square {
+	return Square{s.origin.Translate(dx, dy), s.side}
+}
+
+func (c Circle) Translate(dx, dy float64) Circle {
+	return Circle{c.center.Translate(dx, dy), c.radius}
+}
+
+func (t Triangle) Translate(dx, dy float64) Triangle {
+	return Triangle{t.a.Translate(dx, dy), t.b.Translate(dx, dy), t.c.Translate(dx, dy)}
+}
+
+func (p Polygon) Translate(dx, dy float64) Polygon {
+	points := make([]Point, len(p.points))
+	for i, v := range p.points {
+		points[i] = v.Translate(dx, dy)
+	}
+
+	return Polygon{points}
+}
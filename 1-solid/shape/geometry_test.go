@@ -0,0 +1,80 @@
+package shape
+
+import "testing"
+
+func TestGeometry(t *testing.T) {
+	unit := NewRectangle(Point{}, 1, 1)
+	flat := NewRectangle(Point{Y: 1}, 2, 0)
+	line := NewTriangle(Point{}, Point{X: 2}, Point{X: 4})
+	right := NewTriangle(Point{}, Point{X: 4}, Point{Y: 3})
+	house := NewPolygon(Point{}, Point{X: 2}, Point{X: 2, Y: 2}, Point{X: 1, Y: 3}, Point{Y: 2})
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"a corner is inside its rectangle", unit.Contains(Point{X: 1, Y: 1}), true},
+		{"a point just outside a rectangle", unit.Contains(Point{X: 1.001, Y: 1}), false},
+		{"rectangles sharing an edge intersect", unit.Intersects(unit.Translate(1, 0)), true},
+		{"rectangles sharing a corner intersect", unit.Intersects(unit.Translate(1, 1)), true},
+		{"rectangles just apart don't intersect", unit.Intersects(unit.Translate(1.001, 0)), false},
+		{"a rectangle without height still intersects", unit.Intersects(flat), true},
+		{"a rectangle without size contains its point", NewRectangle(Point{X: 2, Y: 2}, 0, 0).Contains(Point{X: 2, Y: 2}), true},
+		{"a circle touching a side intersects", NewCircle(Point{X: 2, Y: 0.5}, 1).IntersectsRectangle(unit), true},
+		{"a circle near a corner doesn't intersect", NewCircle(Point{X: 2, Y: 2}, 1).IntersectsRectangle(unit), false},
+		{"a circle without radius is a point", NewCircle(Point{X: 0.5, Y: 0.5}, 0).IntersectsRectangle(unit), true},
+		{"a point on a circle is inside it", NewCircle(Point{}, 1).Contains(Point{Y: -1}), true},
+		{"a flat triangle contains its edge", line.Contains(Point{X: 3}), true},
+		{"a flat triangle doesn't contain its line", line.Contains(Point{X: 5}), false},
+		{"a point on a diagonal edge of a triangle", right.Contains(Point{X: 2, Y: 1.5}), true},
+		{"a point on a diagonal edge of a polygon", house.Contains(Point{X: 0.5, Y: 2.5}), true},
+		{"a polygon doesn't contain its notch", house.Contains(Point{X: 0.5, Y: 2.9}), false},
+		{"a polygon contains its inside", house.Contains(Point{X: 1, Y: 1}), true},
+		{"an empty polygon contains nothing", NewPolygon().Contains(Point{}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersection(t *testing.T) {
+	unit := NewRectangle(Point{}, 1, 1)
+
+	tests := []struct {
+		name  string
+		other Rectangle
+		want  Rectangle
+		ok    bool
+	}{
+		{"overlapping", NewRectangle(Point{X: 0.5, Y: 0.5}, 1, 1), NewRectangle(Point{X: 0.5, Y: 0.5}, 0.5, 0.5), true},
+		{"touching along an edge", unit.Translate(1, 0), NewRectangle(Point{X: 1}, 0, 1), true},
+		{"touching at a corner", unit.Translate(1, 1), NewRectangle(Point{X: 1, Y: 1}, 0, 0), true},
+		{"apart", unit.Translate(2, 0), Rectangle{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := unit.Intersection(tt.other)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnionBounds(t *testing.T) {
+	got := UnionBounds(NewRectangle(Point{}, 1, 1), NewCircle(Point{X: 3, Y: 3}, 1))
+	if want := (Bounds{Point{}, Point{X: 4, Y: 4}}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := UnionBounds(); got != (Bounds{}) {
+		t.Errorf("no shapes gave %v", got)
+	}
+}
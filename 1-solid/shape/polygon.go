@@ -0,0 +1,112 @@
+package shape
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Triangles and polygons are defined by their vertices, so scaling moves the vertices away from the first one
+
+type Triangle struct {
+	a, b, c Point
+}
+
+func NewTriangle(a, b, c Point) Triangle {
+	return Triangle{a, b, c}
+}
+
+func (t Triangle) Vertices() [3]Point { return [3]Point{t.a, t.b, t.c} }
+func (t Triangle) Area() float64      { return shoelace(t.a, t.b, t.c) }
+func (t Triangle) Perimeter() float64 { return perimeter(t.a, t.b, t.c) }
+func (t Triangle) Bounds() Bounds     { return boundsOf(t.a, t.b, t.c) }
+
+func (t Triangle) Scale(factor float64) Triangle {
+	return Triangle{t.a, scaleFrom(t.a, t.b, factor), scaleFrom(t.a, t.c, factor)}
+}
+
+func (t Triangle) String() string {
+	return fmt.Sprintf("Triangle %v %v %v", t.a, t.b, t.c)
+}
+
+// Polygon is a simple polygon, its edges must not cross each other
+type Polygon struct {
+	points []Point
+}
+
+// NewPolygon copies the points, so the caller can't change the polygon afterwards
+func NewPolygon(points ...Point) Polygon {
+	return Polygon{append([]Point{}, points...)}
+}
+
+func (p Polygon) Vertices() []Point  { return append([]Point{}, p.points...) }
+func (p Polygon) Area() float64      { return shoelace(p.points...) }
+func (p Polygon) Perimeter() float64 { return perimeter(p.points...) }
+func (p Polygon) Bounds() Bounds     { return boundsOf(p.points...) }
+
+func (p Polygon) Scale(factor float64) Polygon {
+	if len(p.points) == 0 {
+		return p
+	}
+
+	points := make([]Point, len(p.points))
+	for i, v := range p.points {
+		points[i] = scaleFrom(p.points[0], v, factor)
+	}
+
+	return Polygon{points}
+}
+
+func (p Polygon) String() string {
+	parts := make([]string, len(p.points))
+	for i, v := range p.points {
+		parts[i] = v.String()
+	}
+
+	return "Polygon " + strings.Join(parts, " ")
+}
+
+// shoelace gives the area of the polygon with these vertices, in either direction
+func shoelace(points ...Point) float64 {
+	sum := 0.0
+	for i, p := range points {
+		next := points[(i+1)%len(points)]
+		sum += p.X*next.Y - next.X*p.Y
+	}
+
+	return math.Abs(sum) / 2
+}
+
+func perimeter(points ...Point) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	sum := 0.0
+	for i, p := range points {
+		sum += p.distance(points[(i+1)%len(points)])
+	}
+
+	return sum
+}
+
+// boundsOf returns empty bounds at the origin when there are no points
+func boundsOf(points ...Point) Bounds {
+	if len(points) == 0 {
+		return Bounds{}
+	}
+
+	b := Bounds{points[0], points[0]}
+	for _, p := range points[1:] {
+		b.Min.X, b.Min.Y = math.Min(b.Min.X, p.X), math.Min(b.Min.Y, p.Y)
+		b.Max.X, b.Max.Y = math.Max(b.Max.X, p.X), math.Max(b.Max.Y, p.Y)
+	}
+
+	return b
+}
+
+// Negative factors are taken as positive, like the sizes of the other shapes
+func scaleFrom(anchor, p Point, factor float64) Point {
+	factor = math.Abs(factor)
+	return Point{anchor.X + (p.X-anchor.X)*factor, anchor.Y + (p.Y-anchor.Y)*factor}
+}
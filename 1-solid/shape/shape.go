@@ -0,0 +1,157 @@
+package shape
+
+import (
+	"fmt"
+	"math"
+)
+
+// The Sized interface in lsp.go breaks the LSP because it lets callers change one side of a shape,
+// and a Square can't keep that promise without changing the other side too
+// Here shapes never change: WithWidth, Scale and friends return a new shape, and leave the original alone
+// A Square asked for a different width simply becomes a Rectangle, so no caller gets surprised
+
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+	Bounds() Bounds
+	// Contains counts the points on the edges as inside
+	Contains(p Point) bool
+}
+
+type Point struct {
+	X, Y float64
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%g, %g)", p.X, p.Y)
+}
+
+func (p Point) distance(other Point) float64 {
+	return math.Hypot(other.X-p.X, other.Y-p.Y)
+}
+
+// Bounds is the smallest axis-aligned box around a shape
+type Bounds struct {
+	Min, Max Point
+}
+
+func (b Bounds) Width() float64  { return b.Max.X - b.Min.X }
+func (b Bounds) Height() float64 { return b.Max.Y - b.Min.Y }
+
+func (b Bounds) String() string {
+	return fmt.Sprintf("%v-%v", b.Min, b.Max)
+}
+
+// Sizes are distances, so negative ones are taken as positive, in the constructors and in Scale too
+
+// Rectangle is anchored at its bottom-left corner
+type Rectangle struct {
+	origin        Point
+	width, height float64
+}
+
+func NewRectangle(origin Point, width, height float64) Rectangle {
+	return Rectangle{origin, math.Abs(width), math.Abs(height)}
+}
+
+func (r Rectangle) Origin() Point      { return r.origin }
+func (r Rectangle) Width() float64     { return r.width }
+func (r Rectangle) Height() float64    { return r.height }
+func (r Rectangle) Area() float64      { return r.width * r.height }
+func (r Rectangle) Perimeter() float64 { return 2 * (r.width + r.height) }
+
+func (r Rectangle) Bounds() Bounds {
+	return Bounds{r.origin, Point{r.origin.X + r.width, r.origin.Y + r.height}}
+}
+
+func (r Rectangle) WithWidth(width float64) Rectangle {
+	return NewRectangle(r.origin, width, r.height)
+}
+
+func (r Rectangle) WithHeight(height float64) Rectangle {
+	return NewRectangle(r.origin, r.width, height)
+}
+
+// Scale keeps the bottom-left corner where it is
+func (r Rectangle) Scale(factor float64) Rectangle {
+	return NewRectangle(r.origin, r.width*factor, r.height*factor)
+}
+
+func (r Rectangle) String() string {
+	return fmt.Sprintf("Rectangle %gx%g at %v", r.width, r.height, r.origin)
+}
+
+// Square doesn't embed Rectangle this time, it only converts to one
+type Square struct {
+	origin Point
+	side   float64
+}
+
+func NewSquare(origin Point, side float64) Square {
+	return Square{origin, math.Abs(side)}
+}
+
+func (s Square) Origin() Point      { return s.origin }
+func (s Square) Side() float64      { return s.side }
+func (s Square) Area() float64      { return s.side * s.side }
+func (s Square) Perimeter() float64 { return 4 * s.side }
+func (s Square) Bounds() Bounds     { return s.Rectangle().Bounds() }
+
+func (s Square) Rectangle() Rectangle {
+	return NewRectangle(s.origin, s.side, s.side)
+}
+
+func (s Square) WithSide(side float64) Square {
+	return NewSquare(s.origin, side)
+}
+
+// Changing only the width of a square gives us a rectangle, the square itself stays a square
+func (s Square) WithWidth(width float64) Rectangle {
+	return s.Rectangle().WithWidth(width)
+}
+
+func (s Square) WithHeight(height float64) Rectangle {
+	return s.Rectangle().WithHeight(height)
+}
+
+func (s Square) Scale(factor float64) Square {
+	return NewSquare(s.origin, s.side*factor)
+}
+
+func (s Square) String() string {
+	return fmt.Sprintf("Square %g at %v", s.side, s.origin)
+}
+
+type Circle struct {
+	center Point
+	radius float64
+}
+
+func NewCircle(center Point, radius float64) Circle {
+	return Circle{center, math.Abs(radius)}
+}
+
+func (c Circle) Center() Point      { return c.center }
+func (c Circle) Radius() float64    { return c.radius }
+func (c Circle) Area() float64      { return math.Pi * c.radius * c.radius }
+func (c Circle) Perimeter() float64 { return 2 * math.Pi * c.radius }
+
+func (c Circle) Bounds() Bounds {
+	return Bounds{
+		Point{c.center.X - c.radius, c.center.Y - c.radius},
+		Point{c.center.X + c.radius, c.center.Y + c.radius},
+	}
+}
+
+func (c Circle) WithRadius(radius float64) Circle {
+	return NewCircle(c.center, radius)
+}
+
+// Scale keeps the center where it is
+func (c Circle) Scale(factor float64) Circle {
+	return NewCircle(c.center, c.radius*factor)
+}
+
+func (c Circle) String() string {
+	return fmt.Sprintf("Circle of radius %g at %v", c.radius, c.center)
+}
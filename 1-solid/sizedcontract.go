@@ -0,0 +1,143 @@
+package solid
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// UseIt only prints a mismatch for the one case it tries
+// CheckSizedContract spells out what callers expect from any Sized, as named laws,
+// and tries each of them on random widths and heights, zero and negative ones included
+
+// LawViolation is the smallest counterexample found for a law
+type LawViolation struct {
+	Law           string
+	Width, Height int
+	Detail        string
+}
+
+func (lv LawViolation) Error() string {
+	return fmt.Sprintf("%s: with width %d and height %d, %s", lv.Law, lv.Width, lv.Height, lv.Detail)
+}
+
+type sizedLaw struct {
+	name string
+	// check returns what went wrong, or an empty string when the law holds
+	check func(s Sized, width, height int) string
+}
+
+var sizedLaws = []sizedLaw{
+	{"getters reflect setters", func(s Sized, width, height int) string {
+		s.SetWidth(width)
+		s.SetHeight(height)
+		if s.GetWidth() != width || s.GetHeight() != height {
+			return fmt.Sprintf("got %dx%d back", s.GetWidth(), s.GetHeight())
+		}
+		return ""
+	}},
+	{"setting the height leaves the width unchanged", func(s Sized, width, height int) string {
+		s.SetWidth(width)
+		s.SetHeight(height)
+		if s.GetWidth() != width {
+			return fmt.Sprintf("the width became %d", s.GetWidth())
+		}
+		return ""
+	}},
+	{"setting the width leaves the height unchanged", func(s Sized, width, height int) string {
+		s.SetHeight(height)
+		s.SetWidth(width)
+		if s.GetHeight() != height {
+			return fmt.Sprintf("the height became %d", s.GetHeight())
+		}
+		return ""
+	}},
+	{"area is width times height", func(s Sized, width, height int) string {
+		s.SetWidth(width)
+		s.SetHeight(height)
+		if area := s.GetWidth() * s.GetHeight(); area != width*height {
+			return fmt.Sprintf("the area is %d instead of %d", area, width*height)
+		}
+		return ""
+	}},
+	{"a zero side means no area", func(s Sized, width, height int) string {
+		s.SetWidth(0)
+		s.SetHeight(height)
+		if area := s.GetWidth() * s.GetHeight(); area != 0 {
+			return fmt.Sprintf("a zero width and height %d have an area of %d", height, area)
+		}
+		return ""
+	}},
+	{"negative sizes are kept as they are", func(s Sized, width, height int) string {
+		s.SetWidth(-absInt(width))
+		s.SetHeight(-absInt(height))
+		if s.GetWidth() != -absInt(width) || s.GetHeight() != -absInt(height) {
+			return fmt.Sprintf("setting %dx%d gave %dx%d", -absInt(width), -absInt(height), s.GetWidth(), s.GetHeight())
+		}
+		return ""
+	}},
+}
+
+// CheckSizedContract runs every law against fresh values from newSized, runs times each,
+// and returns one violation per broken law, in the order the laws are listed above
+func CheckSizedContract(newSized func() Sized, r *rand.Rand, runs int) []LawViolation {
+	violations := []LawViolation{}
+	for _, law := range sizedLaws {
+		for i := 0; i < runs; i++ {
+			width, height := randomSide(r), randomSide(r)
+			if law.check(newSized(), width, height) != "" {
+				violations = append(violations, shrinkViolation(law, newSized, width, height))
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+// randomSide favours the values implementations tend to get wrong: zero, one and negatives
+func randomSide(r *rand.Rand) int {
+	switch r.Intn(4) {
+	case 0:
+		return []int{0, 1, -1}[r.Intn(3)]
+	case 1:
+		return -1 - r.Intn(100)
+	default:
+		return r.Intn(10000)
+	}
+}
+
+// shrinkViolation moves the counterexample towards zero for as long as the law keeps failing,
+// so the report shows the simplest values that break it
+func shrinkViolation(law sizedLaw, newSized func() Sized, width, height int) LawViolation {
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for _, c := range [][2]int{{width / 2, height}, {width, height / 2}, {width - signInt(width), height}, {width, height - signInt(height)}} {
+			if c != [2]int{width, height} && law.check(newSized(), c[0], c[1]) != "" {
+				width, height = c[0], c[1]
+				shrunk = true
+				break
+			}
+		}
+	}
+
+	return LawViolation{law.name, width, height, law.check(newSized(), width, height)}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+func signInt(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,34 @@
+package solid
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRectangleKeepsTheSizedContract(t *testing.T) {
+	violations := CheckSizedContract(func() Sized { return &Rectangle{} }, rand.New(rand.NewSource(1)), 200)
+	for _, v := range violations {
+		t.Error(v)
+	}
+}
+
+func TestSquareBreaksTheSizedContract(t *testing.T) {
+	violations := CheckSizedContract(func() Sized { return NewSquare(0) }, rand.New(rand.NewSource(1)), 200)
+
+	got := []string{}
+	for _, v := range violations {
+		got = append(got, v.Law)
+	}
+	want := []string{
+		"getters reflect setters",
+		"setting the height leaves the width unchanged",
+		"setting the width leaves the height unchanged",
+		"area is width times height",
+		"a zero side means no area",
+		"negative sizes are kept as they are",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got broken laws %q, want %q", got, want)
+	}
+}
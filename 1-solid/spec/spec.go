@@ -0,0 +1,119 @@
+package spec
+
+// The Specification pattern from ocp.go only works with *Product
+// With generics, the same idea works for any type: employees, journal entries, shapes...
+//
+// Specification[*Product] has exactly the same method as solid.Specification,
+// so every existing product specification already is a Specification[*Product]
+
+type Specification[T any] interface {
+	IsSatisfied(item T) bool
+}
+
+// Func turns a plain function into a specification
+type Func[T any] func(item T) bool
+
+func (f Func[T]) IsSatisfied(item T) bool {
+	return f(item)
+}
+
+type AndSpecification[T any] struct {
+	first, second Specification[T]
+}
+
+func And[T any](first, second Specification[T]) AndSpecification[T] {
+	return AndSpecification[T]{first, second}
+}
+
+func (as AndSpecification[T]) IsSatisfied(item T) bool {
+	return as.first.IsSatisfied(item) && as.second.IsSatisfied(item)
+}
+
+type OrSpecification[T any] struct {
+	first, second Specification[T]
+}
+
+func Or[T any](first, second Specification[T]) OrSpecification[T] {
+	return OrSpecification[T]{first, second}
+}
+
+func (os OrSpecification[T]) IsSatisfied(item T) bool {
+	return os.first.IsSatisfied(item) || os.second.IsSatisfied(item)
+}
+
+type NotSpecification[T any] struct {
+	spec Specification[T]
+}
+
+func Not[T any](spec Specification[T]) NotSpecification[T] {
+	return NotSpecification[T]{spec}
+}
+
+func (ns NotSpecification[T]) IsSatisfied(item T) bool {
+	return !ns.spec.IsSatisfied(item)
+}
+
+// AllOfSpecification is satisfied when every specification is, so an empty one is always satisfied
+type AllOfSpecification[T any] struct {
+	specs []Specification[T]
+}
+
+func AllOf[T any](specs ...Specification[T]) AllOfSpecification[T] {
+	return AllOfSpecification[T]{specs}
+}
+
+func (as AllOfSpecification[T]) IsSatisfied(item T) bool {
+	for _, s := range as.specs {
+		if !s.IsSatisfied(item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AnyOfSpecification is satisfied when any specification is, so an empty one is never satisfied
+type AnyOfSpecification[T any] struct {
+	specs []Specification[T]
+}
+
+func AnyOf[T any](specs ...Specification[T]) AnyOfSpecification[T] {
+	return AnyOfSpecification[T]{specs}
+}
+
+func (as AnyOfSpecification[T]) IsSatisfied(item T) bool {
+	for _, s := range as.specs {
+		if s.IsSatisfied(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter is the BetterFilter for any type
+type Filter[T any] struct{}
+
+func (f Filter[T]) Filter(items []T, spec Specification[T]) []T {
+	result := []T{}
+	for _, item := range items {
+		if spec.IsSatisfied(item) {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// FilterRefs works like BetterFilter.Filter: specifications get pointers to the items,
+// and the result points into the original slice
+func (f Filter[T]) FilterRefs(items []T, spec Specification[*T]) []*T {
+	result := []*T{}
+	for i := range items {
+		if spec.IsSatisfied(&items[i]) {
+			result = append(result, &items[i])
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package solid
+
+// The AndSpecification only takes two specifications, and it's the only combinator we have
+// Thanks to the OCP, adding more of them doesn't touch anything that already exists
+
+// OrSpecification is satisfied when at least one of its specifications is
+type OrSpecification struct {
+	first, second Specification
+}
+
+func (os OrSpecification) IsSatisfied(p *Product) bool {
+	return os.first.IsSatisfied(p) || os.second.IsSatisfied(p)
+}
+
+type NotSpecification struct {
+	spec Specification
+}
+
+func (ns NotSpecification) IsSatisfied(p *Product) bool {
+	return !ns.spec.IsSatisfied(p)
+}
+
+// AllOfSpecification is the AndSpecification for any number of specifications
+// With no specifications at all, every product satisfies it
+type AllOfSpecification struct {
+	specs []Specification
+}
+
+func AllOf(specs ...Specification) AllOfSpecification {
+	return AllOfSpecification{unwrapAll(specs)}
+}
+
+func (as AllOfSpecification) IsSatisfied(p *Product) bool {
+	for _, s := range as.specs {
+		if !s.IsSatisfied(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AnyOfSpecification is the OrSpecification for any number of specifications
+// With no specifications at all, no product satisfies it
+type AnyOfSpecification struct {
+	specs []Specification
+}
+
+func AnyOf(specs ...Specification) AnyOfSpecification {
+	return AnyOfSpecification{unwrapAll(specs)}
+}
+
+func (as AnyOfSpecification) IsSatisfied(p *Product) bool {
+	for _, s := range as.specs {
+		if s.IsSatisfied(p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FluentSpecification lets us chain combinators on any Specification:
+//
+//	Spec(green).Or(blue).And(Spec(large).Not())
+//
+// It only wraps the specification tree, and is left out of the trees it builds
+type FluentSpecification struct {
+	Specification
+}
+
+func Spec(s Specification) FluentSpecification {
+	return FluentSpecification{unwrap(s)}
+}
+
+func (fs FluentSpecification) And(other Specification) FluentSpecification {
+	return FluentSpecification{AndSpecification{fs.Specification, unwrap(other)}}
+}
+
+func (fs FluentSpecification) Or(other Specification) FluentSpecification {
+	return FluentSpecification{OrSpecification{fs.Specification, unwrap(other)}}
+}
+
+func (fs FluentSpecification) Not() FluentSpecification {
+	return FluentSpecification{NotSpecification{fs.Specification}}
+}
+
+func unwrap(s Specification) Specification {
+	for {
+		fs, ok := s.(FluentSpecification)
+		if !ok {
+			return s
+		}
+		s = fs.Specification
+	}
+}
+
+func unwrapAll(specs []Specification) []Specification {
+	result := make([]Specification, len(specs))
+	for i, s := range specs {
+		result[i] = unwrap(s)
+	}
+
+	return result
+}
@@ -0,0 +1,113 @@
+package solid
+
+import (
+	"strings"
+)
+
+// When a composite specification rejects a product, IsSatisfied only tells us "false"
+// Evaluate walks the same tree, but keeps the outcome of every node, so we can see which branch failed
+// It short-circuits just like IsSatisfied: the nodes that didn't need to run are marked as skipped
+
+type Evaluation struct {
+	Spec      Specification
+	Satisfied bool
+	// Skipped nodes were never evaluated, because an earlier sibling already decided the outcome
+	Skipped  bool
+	Children []*Evaluation
+}
+
+func Evaluate(spec Specification, p *Product) *Evaluation {
+	spec = unwrap(spec)
+
+	switch s := spec.(type) {
+	case NotSpecification:
+		child := Evaluate(s.spec, p)
+		return &Evaluation{Spec: s, Satisfied: !child.Satisfied, Children: []*Evaluation{child}}
+	case AndSpecification:
+		return evaluateAll(s, []Specification{s.first, s.second}, p)
+	case AllOfSpecification:
+		return evaluateAll(s, s.specs, p)
+	case OrSpecification:
+		return evaluateAny(s, []Specification{s.first, s.second}, p)
+	case AnyOfSpecification:
+		return evaluateAny(s, s.specs, p)
+	}
+
+	return &Evaluation{Spec: spec, Satisfied: spec.IsSatisfied(p)}
+}
+
+// evaluateAll stops at the first failure, and evaluateAny at the first success
+func evaluateAll(spec Specification, specs []Specification, p *Product) *Evaluation {
+	return evaluateUntil(spec, specs, p, false)
+}
+
+func evaluateAny(spec Specification, specs []Specification, p *Product) *Evaluation {
+	return evaluateUntil(spec, specs, p, true)
+}
+
+func evaluateUntil(spec Specification, specs []Specification, p *Product, decisive bool) *Evaluation {
+	e := &Evaluation{Spec: spec, Satisfied: !decisive}
+
+	for _, s := range specs {
+		if e.Satisfied == decisive {
+			e.Children = append(e.Children, &Evaluation{Spec: unwrap(s), Skipped: true})
+			continue
+		}
+
+		child := Evaluate(s, p)
+		e.Children = append(e.Children, child)
+		if child.Satisfied == decisive {
+			e.Satisfied = decisive
+		}
+	}
+
+	return e
+}
+
+// String renders the evaluation as an indented tree:
+//
+//	FAIL  AND
+//	  PASS  color = green
+//	  FAIL  size = large
+func (e *Evaluation) String() string {
+	sb := strings.Builder{}
+	e.write(&sb, 0)
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (e *Evaluation) write(sb *strings.Builder, indent int) {
+	sb.WriteString(strings.Repeat("  ", indent))
+	switch {
+	case e.Skipped:
+		sb.WriteString("SKIP  ")
+	case e.Satisfied:
+		sb.WriteString("PASS  ")
+	default:
+		sb.WriteString("FAIL  ")
+	}
+	sb.WriteString(evaluationLabel(e.Spec))
+	sb.WriteString("\n")
+
+	for _, child := range e.Children {
+		child.write(sb, indent+1)
+	}
+}
+
+// Composite nodes show their operator, their operands are right below them
+func evaluationLabel(s Specification) string {
+	switch s.(type) {
+	case NotSpecification:
+		return "NOT"
+	case AndSpecification:
+		return "AND"
+	case OrSpecification:
+		return "OR"
+	case AllOfSpecification:
+		return "ALL OF"
+	case AnyOfSpecification:
+		return "ANY OF"
+	}
+
+	return FormatSpecification(s)
+}
@@ -0,0 +1,23 @@
+package solid
+
+import "github.com/pedr0diniz/1-solid/spec"
+
+// The spec package has the generic version of the Specification pattern
+// Our product and entry specifications don't need to change to be used there: the method sets already match
+// These adapters just spell the conversion out, for code that wants to be explicit about it
+
+func GenericProductSpecification(s Specification) spec.Specification[*Product] {
+	return s
+}
+
+func ProductSpecificationFrom(s spec.Specification[*Product]) Specification {
+	return s
+}
+
+func GenericEntrySpecification(s EntrySpecification) spec.Specification[*Entry] {
+	return s
+}
+
+func EntrySpecificationFrom(s spec.Specification[*Entry]) EntrySpecification {
+	return s
+}
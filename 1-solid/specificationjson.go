@@ -0,0 +1,485 @@
+package solid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Saved searches are specification trees, so we need to store them and get them back
+// Every specification is encoded as a JSON object with a "type" discriminator:
+//
+//	{"type": "and", "first": {"type": "color", "color": "green"}, "second": {"type": "size", "size": "large"}}
+//
+// Decoding looks the type up in a registry, and custom specifications can register themselves there too
+
+// SerializableSpecification is a specification that knows its type name in the registry
+// Its JSON encoding must be an object, the "type" field is added when it's missing
+type SerializableSpecification interface {
+	Specification
+	SpecificationType() string
+}
+
+// SpecificationDecoder rebuilds a specification from its JSON object, "type" field included
+type SpecificationDecoder func(data []byte) (Specification, error)
+
+var ErrUnknownSpecificationType = errors.New("unknown specification type")
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]SpecificationDecoder{}
+)
+
+// RegisterSpecification makes a specification type decodable
+// Like database/sql.Register, registering the same name twice is a programming error and panics
+func RegisterSpecification(typeName string, decode SpecificationDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	if typeName == "" || decode == nil {
+		panic("solid: RegisterSpecification needs a type name and a decoder")
+	}
+	if _, ok := decoders[typeName]; ok {
+		panic("solid: RegisterSpecification called twice for " + typeName)
+	}
+	decoders[typeName] = decode
+}
+
+// RegisterSpecificationType registers a specification whose exported fields are all it needs,
+// so plain json.Unmarshal can decode it
+func RegisterSpecificationType[T SerializableSpecification](typeName string) {
+	RegisterSpecification(typeName, func(data []byte) (Specification, error) {
+		var s T
+		err := json.Unmarshal(data, &s)
+		return s, err
+	})
+}
+
+func MarshalSpecification(s Specification) ([]byte, error) {
+	s = unwrap(s)
+	ss, ok := s.(SerializableSpecification)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T doesn't implement SerializableSpecification", ErrUnknownSpecificationType, s)
+	}
+
+	data, err := json.Marshal(ss)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("specification %T must encode as a JSON object: %w", s, err)
+	}
+	if _, ok := fields["type"]; ok {
+		return data, nil
+	}
+
+	fields["type"], _ = json.Marshal(ss.SpecificationType())
+
+	return json.Marshal(fields)
+}
+
+func UnmarshalSpecification(data []byte) (Specification, error) {
+	header := struct {
+		Type string `json:"type"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	decodersMu.RLock()
+	decode, ok := decoders[header.Type]
+	decodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSpecificationType, header.Type)
+	}
+
+	return decode(data)
+}
+
+func unmarshalSpecifications(data []json.RawMessage) ([]Specification, error) {
+	result := make([]Specification, len(data))
+	for i, d := range data {
+		s, err := UnmarshalSpecification(d)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = s
+	}
+
+	return result, nil
+}
+
+func marshalSpecifications(specs []Specification) ([]json.RawMessage, error) {
+	result := make([]json.RawMessage, len(specs))
+	for i, s := range specs {
+		data, err := MarshalSpecification(s)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = data
+	}
+
+	return result, nil
+}
+
+// The JSON shapes of the specifications we ship with
+
+// Required fields are pointers, so a missing field is an error instead of the zero value,
+// e.g. red, or an empty name pattern matching every product
+type colorSpecificationJSON struct {
+	Type  string `json:"type"`
+	Color *Color `json:"color"`
+}
+
+type sizeSpecificationJSON struct {
+	Type string `json:"type"`
+	Size *Size  `json:"size"`
+}
+
+// Infinity has no JSON spelling, so an open side of a range is just left out
+// An open end of a range is written as null, since JSON has no infinities
+type rangeSpecificationJSON struct {
+	Type string   `json:"type"`
+	Min  *float64 `json:"min"`
+	Max  *float64 `json:"max"`
+}
+
+type textSpecificationJSON struct {
+	Type string  `json:"type"`
+	Text *string `json:"text"`
+}
+
+type tagSpecificationJSON struct {
+	Type string  `json:"type"`
+	Tag  *string `json:"tag"`
+}
+
+type nameSpecificationJSON struct {
+	Type string  `json:"type"`
+	Name *string `json:"name"`
+}
+
+type nameMatchSpecificationJSON struct {
+	Type    string  `json:"type"`
+	Pattern *string `json:"pattern"`
+}
+
+type notSpecificationJSON struct {
+	Type string          `json:"type"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+type pairSpecificationJSON struct {
+	Type   string          `json:"type"`
+	First  json.RawMessage `json:"first"`
+	Second json.RawMessage `json:"second"`
+}
+
+type listSpecificationJSON struct {
+	Type  string            `json:"type"`
+	Specs []json.RawMessage `json:"specs"`
+}
+
+func (c ColorSpecification) SpecificationType() string         { return "color" }
+func (s SizeSpecification) SpecificationType() string          { return "size" }
+func (ns NameSpecification) SpecificationType() string         { return "name" }
+func (nm NameMatchSpecification) SpecificationType() string    { return "nameMatch" }
+func (ns NotSpecification) SpecificationType() string          { return "not" }
+func (as AndSpecification) SpecificationType() string          { return "and" }
+func (os OrSpecification) SpecificationType() string           { return "or" }
+func (as AllOfSpecification) SpecificationType() string        { return "allOf" }
+func (as AnyOfSpecification) SpecificationType() string        { return "anyOf" }
+func (pr PriceRangeSpecification) SpecificationType() string   { return "priceRange" }
+func (wr WeightRangeSpecification) SpecificationType() string  { return "weightRange" }
+func (nc NameContainsSpecification) SpecificationType() string { return "nameContains" }
+func (nr NameRegexpSpecification) SpecificationType() string   { return "nameRegexp" }
+func (pt ProductTagSpecification) SpecificationType() string   { return "tag" }
+
+func (c ColorSpecification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(colorSpecificationJSON{c.SpecificationType(), &c.color})
+}
+
+func (s SizeSpecification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sizeSpecificationJSON{s.SpecificationType(), &s.size})
+}
+
+func marshalRange(typeName string, min, max float64) ([]byte, error) {
+	r := rangeSpecificationJSON{Type: typeName}
+	if !math.IsInf(min, -1) {
+		r.Min = &min
+	}
+	if !math.IsInf(max, 1) {
+		r.Max = &max
+	}
+
+	return json.Marshal(r)
+}
+
+func (pr PriceRangeSpecification) MarshalJSON() ([]byte, error) {
+	return marshalRange(pr.SpecificationType(), pr.min, pr.max)
+}
+
+func (wr WeightRangeSpecification) MarshalJSON() ([]byte, error) {
+	return marshalRange(wr.SpecificationType(), wr.min, wr.max)
+}
+
+func (nc NameContainsSpecification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(textSpecificationJSON{nc.SpecificationType(), &nc.text})
+}
+
+func (nr NameRegexpSpecification) MarshalJSON() ([]byte, error) {
+	pattern := nr.re.String()
+	return json.Marshal(nameMatchSpecificationJSON{nr.SpecificationType(), &pattern})
+}
+
+func (pt ProductTagSpecification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tagSpecificationJSON{pt.SpecificationType(), &pt.tag})
+}
+
+func (ns NameSpecification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nameSpecificationJSON{ns.SpecificationType(), &ns.name})
+}
+
+func (nm NameMatchSpecification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nameMatchSpecificationJSON{nm.SpecificationType(), &nm.pattern})
+}
+
+func (ns NotSpecification) MarshalJSON() ([]byte, error) {
+	spec, err := MarshalSpecification(ns.spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(notSpecificationJSON{ns.SpecificationType(), spec})
+}
+
+func marshalPair(typeName string, first, second Specification) ([]byte, error) {
+	f, err := MarshalSpecification(first)
+	if err != nil {
+		return nil, err
+	}
+	s, err := MarshalSpecification(second)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(pairSpecificationJSON{typeName, f, s})
+}
+
+func (as AndSpecification) MarshalJSON() ([]byte, error) {
+	return marshalPair(as.SpecificationType(), as.first, as.second)
+}
+
+func (os OrSpecification) MarshalJSON() ([]byte, error) {
+	return marshalPair(os.SpecificationType(), os.first, os.second)
+}
+
+func (as AllOfSpecification) MarshalJSON() ([]byte, error) {
+	specs, err := marshalSpecifications(as.specs)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(listSpecificationJSON{as.SpecificationType(), specs})
+}
+
+func (as AnyOfSpecification) MarshalJSON() ([]byte, error) {
+	specs, err := marshalSpecifications(as.specs)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(listSpecificationJSON{as.SpecificationType(), specs})
+}
+
+func decodePair(data []byte) (Specification, Specification, error) {
+	pair := pairSpecificationJSON{}
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return nil, nil, err
+	}
+
+	first, err := UnmarshalSpecification(pair.First)
+	if err != nil {
+		return nil, nil, err
+	}
+	second, err := UnmarshalSpecification(pair.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return first, second, nil
+}
+
+// A null bound is an open end, but a range with neither bound is most likely a typo, so it's an error
+func decodeRange(data []byte) (float64, float64, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, 0, err
+	}
+	_, hasMin := fields["min"]
+	_, hasMax := fields["max"]
+	if !hasMin && !hasMax {
+		return 0, 0, errors.New(`range specification without a "min" or a "max"`)
+	}
+
+	r := rangeSpecificationJSON{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return 0, 0, err
+	}
+
+	min, max := math.Inf(-1), math.Inf(1)
+	if r.Min != nil {
+		min = *r.Min
+	}
+	if r.Max != nil {
+		max = *r.Max
+	}
+
+	return min, max, nil
+}
+
+func decodeList(data []byte) ([]Specification, error) {
+	list := listSpecificationJSON{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	return unmarshalSpecifications(list.Specs)
+}
+
+func init() {
+	RegisterSpecification("color", func(data []byte) (Specification, error) {
+		c := colorSpecificationJSON{}
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, err
+		}
+		if c.Color == nil {
+			return nil, errors.New(`color specification without a "color"`)
+		}
+		return ColorSpecification{*c.Color}, nil
+	})
+
+	RegisterSpecification("size", func(data []byte) (Specification, error) {
+		s := sizeSpecificationJSON{}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		if s.Size == nil {
+			return nil, errors.New(`size specification without a "size"`)
+		}
+		return SizeSpecification{*s.Size}, nil
+	})
+
+	RegisterSpecification("name", func(data []byte) (Specification, error) {
+		n := nameSpecificationJSON{}
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		if n.Name == nil {
+			return nil, errors.New(`name specification without a "name"`)
+		}
+		return NameSpecification{*n.Name}, nil
+	})
+
+	RegisterSpecification("nameMatch", func(data []byte) (Specification, error) {
+		n := nameMatchSpecificationJSON{}
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		if n.Pattern == nil {
+			return nil, errors.New(`nameMatch specification without a "pattern"`)
+		}
+		return NameMatchSpecification{*n.Pattern}, nil
+	})
+
+	RegisterSpecification("priceRange", func(data []byte) (Specification, error) {
+		min, max, err := decodeRange(data)
+		return PriceRangeSpecification{min, max}, err
+	})
+
+	RegisterSpecification("weightRange", func(data []byte) (Specification, error) {
+		min, max, err := decodeRange(data)
+		return WeightRangeSpecification{min, max}, err
+	})
+
+	RegisterSpecification("nameContains", func(data []byte) (Specification, error) {
+		t := textSpecificationJSON{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+		if t.Text == nil {
+			return nil, errors.New(`nameContains specification without a "text"`)
+		}
+		return NameContainsSpecification{*t.Text}, nil
+	})
+
+	RegisterSpecification("nameRegexp", func(data []byte) (Specification, error) {
+		n := nameMatchSpecificationJSON{}
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		if n.Pattern == nil {
+			return nil, errors.New(`nameRegexp specification without a "pattern"`)
+		}
+		return NewNameRegexpSpecification(*n.Pattern)
+	})
+
+	RegisterSpecification("tag", func(data []byte) (Specification, error) {
+		t := tagSpecificationJSON{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+		if t.Tag == nil {
+			return nil, errors.New(`tag specification without a "tag"`)
+		}
+		return ProductTagSpecification{*t.Tag}, nil
+	})
+
+	RegisterSpecification("not", func(data []byte) (Specification, error) {
+		n := notSpecificationJSON{}
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		spec, err := UnmarshalSpecification(n.Spec)
+		if err != nil {
+			return nil, err
+		}
+		return NotSpecification{spec}, nil
+	})
+
+	RegisterSpecification("and", func(data []byte) (Specification, error) {
+		first, second, err := decodePair(data)
+		if err != nil {
+			return nil, err
+		}
+		return AndSpecification{first, second}, nil
+	})
+
+	RegisterSpecification("or", func(data []byte) (Specification, error) {
+		first, second, err := decodePair(data)
+		if err != nil {
+			return nil, err
+		}
+		return OrSpecification{first, second}, nil
+	})
+
+	RegisterSpecification("allOf", func(data []byte) (Specification, error) {
+		specs, err := decodeList(data)
+		if err != nil {
+			return nil, err
+		}
+		return AllOfSpecification{specs}, nil
+	})
+
+	RegisterSpecification("anyOf", func(data []byte) (Specification, error) {
+		specs, err := decodeList(data)
+		if err != nil {
+			return nil, err
+		}
+		return AnyOfSpecification{specs}, nil
+	})
+}
@@ -0,0 +1,71 @@
+package solid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnmarshalSpecificationRejectsMissingFields(t *testing.T) {
+	for _, data := range []string{
+		`{"type":"color"}`,
+		`{"type":"size"}`,
+		`{"type":"color","colour":"green"}`,
+		`{"type":"color","color":"purple"}`,
+		`{"type":"name"}`,
+		`{"type":"nameMatch"}`,
+		`{"type":"nameContains"}`,
+		`{"type":"nameRegexp"}`,
+		`{"type":"tag"}`,
+		`{"type":"priceRange"}`,
+		`{"type":"weightRange"}`,
+	} {
+		if spec, err := UnmarshalSpecification([]byte(data)); err == nil {
+			t.Errorf("%s decoded to %s", data, spec)
+		}
+	}
+}
+
+func TestSpecificationJSONRoundTrip(t *testing.T) {
+	spec, err := ParseSpecification(`color = red AND size != small OR price < 10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalSpecification(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalSpecification(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if FormatSpecification(decoded) != FormatSpecification(spec) {
+		t.Errorf("got %s back from %s", decoded, spec)
+	}
+}
+
+func TestRangeSpecificationJSONOpenEnds(t *testing.T) {
+	for _, spec := range []Specification{
+		PriceRangeSpecification{10, math.Inf(1)},
+		PriceRangeSpecification{math.Inf(-1), 10},
+		WeightRangeSpecification{math.Inf(-1), math.Inf(1)},
+	} {
+		data, err := MarshalSpecification(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UnmarshalSpecification(data)
+		if err != nil {
+			t.Errorf("%s: %v", data, err)
+			continue
+		}
+		if decoded != spec {
+			t.Errorf("got %#v back from %s", decoded, data)
+		}
+	}
+
+	// Searches saved before open ends were written as null leave the key out
+	if _, err := UnmarshalSpecification([]byte(`{"type":"priceRange","min":5}`)); err != nil {
+		t.Errorf("a range with only a min: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package solid
+
+import "math"
+
+// Specifications built with combinators pile up redundant parts, e.g. And(green, And(green, large)) or NOT NOT large
+// Normalize returns a specification matching exactly the same products, but cheaper to check:
+//
+//   - nested ANDs and ORs are flattened into AllOf and AnyOf
+//   - repeated operands are dropped, and NOT NOT x becomes x
+//   - price and weight ranges in the same AND are merged into one
+//   - TRUE and FALSE are folded into their parents
+//   - contradictions like color = red AND color = green become FALSE, and x OR NOT x becomes TRUE
+//
+// Operands keep their order, so short-circuiting still checks them in the order they were written
+func Normalize(spec Specification) Specification {
+	switch s := unwrap(spec).(type) {
+	case NotSpecification:
+		return normalizeNot(Normalize(s.spec))
+	case AndSpecification:
+		return normalizeAll([]Specification{s.first, s.second})
+	case AllOfSpecification:
+		return normalizeAll(s.specs)
+	case OrSpecification:
+		return normalizeAny([]Specification{s.first, s.second})
+	case AnyOfSpecification:
+		return normalizeAny(s.specs)
+	case PriceRangeSpecification:
+		if emptyRange(s.min, s.max) {
+			return AnyOf()
+		}
+	case WeightRangeSpecification:
+		if emptyRange(s.min, s.max) {
+			return AnyOf()
+		}
+	}
+
+	return unwrap(spec)
+}
+
+func isTrue(s Specification) bool {
+	all, ok := s.(AllOfSpecification)
+	return ok && len(all.specs) == 0
+}
+
+func isFalse(s Specification) bool {
+	some, ok := s.(AnyOfSpecification)
+	return ok && len(some.specs) == 0
+}
+
+// A range with a NaN bound is empty too, as no comparison with NaN is ever true
+func emptyRange(min, max float64) bool {
+	return !(min <= max)
+}
+
+func normalizeNot(s Specification) Specification {
+	switch {
+	case isTrue(s):
+		return AnyOf()
+	case isFalse(s):
+		return AllOf()
+	}
+	if not, ok := s.(NotSpecification); ok {
+		return not.spec
+	}
+
+	return NotSpecification{s}
+}
+
+func normalizeAll(specs []Specification) Specification {
+	operands := []Specification{}
+	for _, s := range specs {
+		n := Normalize(s)
+		if isFalse(n) {
+			return AnyOf()
+		}
+		// A normalized AllOf is already flat, and TRUE has no operands to add
+		if all, ok := n.(AllOfSpecification); ok {
+			operands = append(operands, all.specs...)
+			continue
+		}
+		operands = append(operands, n)
+	}
+
+	operands, keys := dedupeSpecifications(operands)
+	if hasComplement(operands, keys) {
+		return AnyOf()
+	}
+	operands, ok := mergeConstraints(operands)
+	if !ok {
+		return AnyOf()
+	}
+
+	if len(operands) == 1 {
+		return operands[0]
+	}
+
+	return AllOfSpecification{operands}
+}
+
+func normalizeAny(specs []Specification) Specification {
+	operands := []Specification{}
+	for _, s := range specs {
+		n := Normalize(s)
+		if isTrue(n) {
+			return AllOf()
+		}
+		if some, ok := n.(AnyOfSpecification); ok {
+			operands = append(operands, some.specs...)
+			continue
+		}
+		operands = append(operands, n)
+	}
+
+	operands, keys := dedupeSpecifications(operands)
+	if hasComplement(operands, keys) {
+		return AllOf()
+	}
+
+	if len(operands) == 1 {
+		return operands[0]
+	}
+
+	return AnyOfSpecification{operands}
+}
+
+// specificationKey is the canonical query form of a specification, which is the same for specifications
+// matching the same products the same way
+// Specifications the query language doesn't know about all print as their type, so they get no key
+func specificationKey(s Specification) (string, bool) {
+	if !isKnownSpecification(s) {
+		return "", false
+	}
+
+	return FormatSpecification(s), true
+}
+
+func isKnownSpecification(s Specification) bool {
+	if _, _, _, ok := formatComparison(s); ok {
+		return true
+	}
+
+	switch spec := s.(type) {
+	case PriceRangeSpecification, WeightRangeSpecification:
+		return true
+	case NotSpecification:
+		return isKnownSpecification(spec.spec)
+	case AndSpecification:
+		return isKnownSpecification(spec.first) && isKnownSpecification(spec.second)
+	case OrSpecification:
+		return isKnownSpecification(spec.first) && isKnownSpecification(spec.second)
+	case AllOfSpecification:
+		return areKnownSpecifications(spec.specs)
+	case AnyOfSpecification:
+		return areKnownSpecifications(spec.specs)
+	}
+
+	return false
+}
+
+func areKnownSpecifications(specs []Specification) bool {
+	for _, s := range specs {
+		if !isKnownSpecification(s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func dedupeSpecifications(specs []Specification) ([]Specification, map[string]bool) {
+	result := []Specification{}
+	keys := map[string]bool{}
+	for _, s := range specs {
+		key, ok := specificationKey(s)
+		if ok && keys[key] {
+			continue
+		}
+		if ok {
+			keys[key] = true
+		}
+		result = append(result, s)
+	}
+
+	return result, keys
+}
+
+// hasComplement finds an operand next to its own negation
+func hasComplement(specs []Specification, keys map[string]bool) bool {
+	for _, s := range specs {
+		not, ok := s.(NotSpecification)
+		if !ok {
+			continue
+		}
+		if key, ok := specificationKey(not.spec); ok && keys[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeConstraints uses what we know about products inside an AND: a product has a single color, size and name,
+// and a single price and weight, so their ranges can be intersected
+// It returns false when no product can satisfy all of the operands
+func mergeConstraints(specs []Specification) ([]Specification, bool) {
+	var color *Color
+	var size *Size
+	var name *string
+	price, weight := -1, -1
+
+	merged := []Specification{}
+	for _, s := range specs {
+		switch spec := s.(type) {
+		case ColorSpecification:
+			if color != nil && *color != spec.color {
+				return nil, false
+			}
+			color = &spec.color
+		case SizeSpecification:
+			if size != nil && *size != spec.size {
+				return nil, false
+			}
+			size = &spec.size
+		case NameSpecification:
+			if name != nil && *name != spec.name {
+				return nil, false
+			}
+			name = &spec.name
+		case PriceRangeSpecification:
+			if price >= 0 {
+				other := merged[price].(PriceRangeSpecification)
+				min, max := intersectRanges(other.min, other.max, spec.min, spec.max)
+				if emptyRange(min, max) {
+					return nil, false
+				}
+				merged[price] = PriceRangeSpecification{min, max}
+				continue
+			}
+			price = len(merged)
+		case WeightRangeSpecification:
+			if weight >= 0 {
+				other := merged[weight].(WeightRangeSpecification)
+				min, max := intersectRanges(other.min, other.max, spec.min, spec.max)
+				if emptyRange(min, max) {
+					return nil, false
+				}
+				merged[weight] = WeightRangeSpecification{min, max}
+				continue
+			}
+			weight = len(merged)
+		}
+		merged = append(merged, s)
+	}
+
+	// Once the color is known, color != anything else says nothing new, and the same goes for size and name
+	result := []Specification{}
+	for _, s := range merged {
+		not, ok := s.(NotSpecification)
+		if !ok {
+			result = append(result, s)
+			continue
+		}
+
+		switch not.spec.(type) {
+		case ColorSpecification:
+			if color != nil {
+				continue
+			}
+		case SizeSpecification:
+			if size != nil {
+				continue
+			}
+		case NameSpecification:
+			if name != nil {
+				continue
+			}
+		}
+		result = append(result, s)
+	}
+
+	return result, true
+}
+
+func intersectRanges(min1, max1, min2, max2 float64) (float64, float64) {
+	return math.Max(min1, min2), math.Min(max1, max2)
+}
@@ -0,0 +1,117 @@
+package solid
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Normalizing must never change which products a specification matches
+func TestNormalizeKeepsMeaning(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	products := make([]Product, 200)
+	for i := range products {
+		products[i] = randomProduct(r)
+	}
+
+	for i := 0; i < 5000; i++ {
+		spec := randomSpecification(r, 5)
+		normalized := Normalize(spec)
+		for k := range products {
+			if spec.IsSatisfied(&products[k]) != normalized.IsSatisfied(&products[k]) {
+				t.Errorf("%s and its normal form %s disagree on %s", spec, FormatSpecification(normalized), products[k].name)
+				break
+			}
+		}
+		if again := FormatSpecification(Normalize(normalized)); again != FormatSpecification(normalized) {
+			t.Errorf("normalizing %s again gave %s", FormatSpecification(normalized), again)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	greenSpec, largeSpec := ColorSpecification{green}, SizeSpecification{large}
+	tests := []struct {
+		name string
+		spec Specification
+		want string
+	}{
+		{"nested ands are flattened and deduplicated", AndSpecification{greenSpec, AndSpecification{greenSpec, largeSpec}}, "color = green AND size = large"},
+		{"double negation", NotSpecification{NotSpecification{largeSpec}}, "size = large"},
+		{"different colors contradict", AllOf(ColorSpecification{red}, greenSpec), "FALSE"},
+		{"a spec and its negation contradict", AllOf(largeSpec, NotSpecification{largeSpec}), "FALSE"},
+		{"a spec or its negation is always true", AnyOf(largeSpec, NotSpecification{largeSpec}), "TRUE"},
+		{"ranges are merged", AllOf(NewPriceRangeSpecification(0, 10), NewPriceRangeSpecification(5, 20)), "price >= 5 AND price <= 10"},
+		{"disjoint ranges contradict", AllOf(NewPriceRangeSpecification(0, 1), NewPriceRangeSpecification(2, 3)), "FALSE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSpecification(Normalize(tt.spec)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// randomSpecification builds specification trees out of a handful of values, so that duplicates,
+// double negations and contradictions show up often
+func randomSpecification(r *rand.Rand, depth int) Specification {
+	if depth == 0 || r.Intn(3) == 0 {
+		switch r.Intn(6) {
+		case 0:
+			return ColorSpecification{Color(r.Intn(3))}
+		case 1:
+			return SizeSpecification{Size(r.Intn(3))}
+		case 2:
+			return NameSpecification{randomNames[r.Intn(len(randomNames))]}
+		case 3:
+			min, max := openRange(randomOperators[r.Intn(len(randomOperators))], randomAmounts[r.Intn(len(randomAmounts))])
+			return PriceRangeSpecification{min, max}
+		case 4:
+			min, max := openRange(randomOperators[r.Intn(len(randomOperators))], randomAmounts[r.Intn(len(randomAmounts))])
+			return WeightRangeSpecification{min, max}
+		default:
+			return ProductTagSpecification{randomTags[r.Intn(len(randomTags))]}
+		}
+	}
+
+	switch r.Intn(5) {
+	case 0:
+		return NotSpecification{randomSpecification(r, depth-1)}
+	case 1:
+		return AndSpecification{randomSpecification(r, depth-1), randomSpecification(r, depth-1)}
+	case 2:
+		return OrSpecification{randomSpecification(r, depth-1), randomSpecification(r, depth-1)}
+	}
+
+	specs := make([]Specification, r.Intn(4))
+	for i := range specs {
+		specs[i] = randomSpecification(r, depth-1)
+	}
+	if r.Intn(2) == 0 {
+		return AllOf(specs...)
+	}
+
+	return AnyOf(specs...)
+}
+
+var (
+	randomNames     = []string{"Apple", "Tree", "House"}
+	randomTags      = []string{"fruit", "garden"}
+	randomAmounts   = []float64{0, 0.5, 10, 120}
+	randomOperators = []string{"=", "<", "<=", ">", ">="}
+)
+
+func randomProduct(r *rand.Rand) Product {
+	tags := []string{}
+	for _, t := range randomTags {
+		if r.Intn(2) == 0 {
+			tags = append(tags, t)
+		}
+	}
+
+	return NewProduct(randomNames[r.Intn(len(randomNames))], Color(r.Intn(3)), Size(r.Intn(3)),
+		WithPrice(randomAmounts[r.Intn(len(randomAmounts))]),
+		WithWeight(randomAmounts[r.Intn(len(randomAmounts))]),
+		WithProductTags(tags...))
+}
@@ -0,0 +1,571 @@
+package solid
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Building ColorSpecification{green} and friends by hand is fine for us, but not for our users
+// So we give them a small query language that compiles into the same specification trees:
+//
+//	color = green AND (size = large OR name ~ "Tr*")
+//
+// Every field supports = and !=, and some have more operators:
+//
+//	name   ~ (glob), contains (ignoring case), matches (regular expression)
+//	price  <, <=, >, >=
+//	weight <, <=, >, >=
+//	color, size and tag only have = and != (tag = fruit means the product has that tag)
+//
+// Expressions combine with NOT, AND and OR (in that order of precedence), parentheses, TRUE and FALSE
+
+// NameSpecification matches a product's exact name
+type NameSpecification struct {
+	name string
+}
+
+func (ns NameSpecification) IsSatisfied(p *Product) bool {
+	return p.name == ns.name
+}
+
+// NameMatchSpecification matches names against a glob pattern, e.g. "Tr*"
+type NameMatchSpecification struct {
+	pattern string
+}
+
+func (nm NameMatchSpecification) IsSatisfied(p *Product) bool {
+	ok, _ := path.Match(nm.pattern, p.name)
+	return ok
+}
+
+// QueryError points at the column of the query where parsing failed
+type QueryError struct {
+	Pos int
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query error at column %d: %s", e.Pos, e.Msg)
+}
+
+type queryTokenKind int
+
+const (
+	tokenEOF queryTokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenOperator
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+
+	return fmt.Sprintf("%q", t.text)
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		pos := i + 1
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{tokenLParen, "(", pos})
+			i++
+		case c == ')':
+			tokens = append(tokens, queryToken{tokenRParen, ")", pos})
+			i++
+		case strings.ContainsRune("=!~<>", rune(c)):
+			op := query[i : i+1]
+			if i+1 < len(query) && query[i+1] == '=' && c != '=' && c != '~' {
+				op = query[i : i+2]
+			}
+			if op == "!" {
+				return nil, &QueryError{pos, "expected != but found \"!\""}
+			}
+			tokens = append(tokens, queryToken{tokenOperator, op, pos})
+			i += len(op)
+		case c >= '0' && c <= '9' || (c == '-' || c == '.') && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			end := i + 1
+			for end < len(query) && strings.ContainsRune("0123456789.eE", rune(query[end])) {
+				if (query[end] == 'e' || query[end] == 'E') && end+1 < len(query) && (query[end+1] == '-' || query[end+1] == '+') {
+					end++
+				}
+				end++
+			}
+			if _, err := strconv.ParseFloat(query[i:end], 64); err != nil {
+				return nil, &QueryError{pos, "invalid number " + query[i:end]}
+			}
+			tokens = append(tokens, queryToken{tokenNumber, query[i:end], pos})
+			i = end
+		case c == '"':
+			end := i + 1
+			for end < len(query) && query[end] != '"' {
+				if query[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(query) {
+				return nil, &QueryError{pos, "unterminated string"}
+			}
+			text, err := strconv.Unquote(query[i : end+1])
+			if err != nil {
+				return nil, &QueryError{pos, "invalid string " + query[i:end+1]}
+			}
+			tokens = append(tokens, queryToken{tokenString, text, pos})
+			i = end + 1
+		default:
+			end := strings.IndexFunc(query[i:], func(r rune) bool { return !isIdentRune(r) })
+			if end == 0 {
+				r, _ := utf8.DecodeRuneInString(query[i:])
+				return nil, &QueryError{pos, fmt.Sprintf("unexpected character %q", r)}
+			}
+			if end < 0 {
+				end = len(query) - i
+			}
+			tokens = append(tokens, queryToken{tokenIdent, query[i : i+end], pos})
+			i += end
+		}
+	}
+
+	return append(tokens, queryToken{tokenEOF, "", len(query) + 1}), nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	next   int
+}
+
+// ParseSpecification compiles a query into a specification tree
+func ParseSpecification(query string) (Specification, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	spec, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &QueryError{t.pos, "unexpected " + t.String()}
+	}
+
+	return spec, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) advance() queryToken {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+
+	return t
+}
+
+func (p *queryParser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.advance()
+		return true
+	}
+
+	return false
+}
+
+func (p *queryParser) parseOr() (Specification, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = OrSpecification{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (Specification, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("AND") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = AndSpecification{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (Specification, error) {
+	if p.keyword("NOT") {
+		spec, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return NotSpecification{spec}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (Specification, error) {
+	t := p.peek()
+
+	switch {
+	case t.kind == tokenLParen:
+		p.advance()
+		spec, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokenRParen {
+			return nil, &QueryError{closing.pos, "expected \")\" but found " + closing.String()}
+		}
+		return spec, nil
+	case p.keyword("TRUE"):
+		return AllOf(), nil
+	case p.keyword("FALSE"):
+		return AnyOf(), nil
+	case t.kind == tokenIdent:
+		return p.parseComparison()
+	}
+
+	return nil, &QueryError{t.pos, "expected a condition but found " + t.String()}
+}
+
+func (p *queryParser) parseComparison() (Specification, error) {
+	field := p.advance()
+
+	op := p.advance()
+	if op.kind == tokenIdent && (strings.EqualFold(op.text, "contains") || strings.EqualFold(op.text, "matches")) {
+		op.kind, op.text = tokenOperator, strings.ToLower(op.text)
+	}
+	if op.kind != tokenOperator {
+		return nil, &QueryError{op.pos, "expected an operator but found " + op.String()}
+	}
+
+	value := p.advance()
+	if value.kind != tokenIdent && value.kind != tokenString && value.kind != tokenNumber {
+		return nil, &QueryError{value.pos, "expected a value but found " + value.String()}
+	}
+
+	return compileComparison(field, op, value)
+}
+
+// compileComparison turns "field op value" into a specification, != being NOT of =
+func compileComparison(field, op, value queryToken) (Specification, error) {
+	badOperator := &QueryError{op.pos, fmt.Sprintf("%s doesn't work with %s", op.text, field.text)}
+	negate := op.text == "!="
+	if negate {
+		op.text = "="
+	}
+
+	var spec Specification
+	switch strings.ToLower(field.text) {
+	case "color":
+		color, err := ParseColor(value.text)
+		if err != nil {
+			return nil, &QueryError{value.pos, err.Error()}
+		}
+		spec = ColorSpecification{color}
+	case "size":
+		size, err := ParseSize(value.text)
+		if err != nil {
+			return nil, &QueryError{value.pos, err.Error()}
+		}
+		spec = SizeSpecification{size}
+	case "tag":
+		spec = ProductTagSpecification{value.text}
+	case "name":
+		switch op.text {
+		case "=":
+			spec = NameSpecification{value.text}
+		case "~":
+			if _, err := path.Match(value.text, ""); err != nil {
+				return nil, &QueryError{value.pos, "invalid pattern " + value.String()}
+			}
+			return NameMatchSpecification{value.text}, nil
+		case "contains":
+			return NameContainsSpecification{value.text}, nil
+		case "matches":
+			re, err := NewNameRegexpSpecification(value.text)
+			if err != nil {
+				return nil, &QueryError{value.pos, "invalid regular expression " + value.String()}
+			}
+			return re, nil
+		default:
+			return nil, badOperator
+		}
+	case "price", "weight":
+		if value.kind != tokenNumber {
+			return nil, &QueryError{value.pos, "expected a number but found " + value.String()}
+		}
+		switch op.text {
+		case "=", "<", "<=", ">", ">=":
+		default:
+			return nil, badOperator
+		}
+		number, _ := strconv.ParseFloat(value.text, 64)
+		min, max := openRange(op.text, number)
+		if strings.EqualFold(field.text, "price") {
+			spec = PriceRangeSpecification{min, max}
+		} else {
+			spec = WeightRangeSpecification{min, max}
+		}
+	default:
+		return nil, &QueryError{field.pos, "unknown field " + field.String()}
+	}
+
+	switch {
+	case op.text != "=" && !isRangeSpecification(spec):
+		return nil, badOperator
+	case negate:
+		return NotSpecification{spec}, nil
+	}
+
+	return spec, nil
+}
+
+func isRangeSpecification(s Specification) bool {
+	switch s.(type) {
+	case PriceRangeSpecification, WeightRangeSpecification:
+		return true
+	}
+
+	return false
+}
+
+// Printing goes the other way around: every specification the parser knows renders back as a query
+// Operator precedence decides where parentheses are needed, so equivalent trees print the same way
+
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceNot
+	precedenceAtom
+)
+
+// FormatSpecification prints a specification tree in canonical query form
+// Specifications the query language doesn't know about are printed by their type
+func FormatSpecification(s Specification) string {
+	text, _ := formatSpecification(s)
+	return text
+}
+
+func formatSpecification(s Specification) (string, int) {
+	if field, op, value, ok := formatComparison(s); ok {
+		return field + " " + op + " " + value, precedenceAtom
+	}
+
+	switch spec := s.(type) {
+	case FluentSpecification:
+		return formatSpecification(spec.Specification)
+	case PriceRangeSpecification:
+		return formatRange("price", spec.min, spec.max)
+	case WeightRangeSpecification:
+		return formatRange("weight", spec.min, spec.max)
+	case NotSpecification:
+		// NOT on a plain comparison reads better as !=
+		// A list of one prints as its only operand, so it has to be looked through here too, or the same
+		// specification would print differently depending on how it's wrapped
+		operand := soleOperand(spec.spec)
+		if field, op, value, ok := formatComparison(operand); ok && op == "=" {
+			return field + " != " + value, precedenceAtom
+		}
+		return "NOT " + formatOperand(operand, precedenceNot), precedenceNot
+	case AndSpecification:
+		return formatOperand(spec.first, precedenceAnd) + " AND " + formatOperand(spec.second, precedenceAnd), precedenceAnd
+	case OrSpecification:
+		return formatOperand(spec.first, precedenceOr) + " OR " + formatOperand(spec.second, precedenceOr), precedenceOr
+	case AllOfSpecification:
+		return formatList(spec.specs, " AND ", "TRUE", precedenceAnd)
+	case AnyOfSpecification:
+		return formatList(spec.specs, " OR ", "FALSE", precedenceOr)
+	}
+
+	return fmt.Sprintf("%T", s), precedenceAtom
+}
+
+// formatComparison splits the specifications that are a single comparison into their parts
+func formatComparison(s Specification) (field, op, value string, ok bool) {
+	switch spec := s.(type) {
+	case ColorSpecification:
+		return "color", "=", spec.color.String(), true
+	case SizeSpecification:
+		return "size", "=", spec.size.String(), true
+	case ProductTagSpecification:
+		return "tag", "=", strconv.Quote(spec.tag), true
+	case NameSpecification:
+		return "name", "=", strconv.Quote(spec.name), true
+	case NameMatchSpecification:
+		return "name", "~", strconv.Quote(spec.pattern), true
+	case NameContainsSpecification:
+		return "name", "contains", strconv.Quote(spec.text), true
+	case NameRegexpSpecification:
+		return "name", "matches", strconv.Quote(spec.re.String()), true
+	case PriceRangeSpecification:
+		return rangeComparison("price", spec.min, spec.max)
+	case WeightRangeSpecification:
+		return rangeComparison("weight", spec.min, spec.max)
+	}
+
+	return "", "", "", false
+}
+
+// rangeComparison handles ranges that fit in one comparison, i.e. with one open side or a single value
+func rangeComparison(field string, min, max float64) (string, string, string, bool) {
+	switch {
+	case min == max:
+		return field, "=", formatNumber(min), true
+	case math.IsInf(min, -1) && !math.IsInf(max, 1):
+		if below, ok := strictBound(max, math.Inf(1)); ok {
+			return field, "<", below, true
+		}
+		return field, "<=", formatNumber(max), true
+	case math.IsInf(max, 1) && !math.IsInf(min, -1):
+		if above, ok := strictBound(min, math.Inf(-1)); ok {
+			return field, ">", above, true
+		}
+		return field, ">=", formatNumber(min), true
+	}
+
+	return "", "", "", false
+}
+
+// Closed ranges take two comparisons, and a fully open one is always true
+func formatRange(field string, min, max float64) (string, int) {
+	if math.IsInf(min, -1) && math.IsInf(max, 1) {
+		return "TRUE", precedenceAtom
+	}
+
+	_, lowerOp, lower, _ := rangeComparison(field, min, math.Inf(1))
+	_, upperOp, upper, _ := rangeComparison(field, math.Inf(-1), max)
+
+	return field + " " + lowerOp + " " + lower + " AND " + field + " " + upperOp + " " + upper, precedenceAnd
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// strictBound finds out whether bound came from a strict comparison: "price > 10" is stored as a minimum
+// of the float right after 10, so if the float next to bound has a shorter spelling, that's what the user wrote
+func strictBound(bound, towards float64) (string, bool) {
+	original := math.Nextafter(bound, towards)
+	if math.Nextafter(original, -towards) != bound {
+		return "", false
+	}
+	if len(formatNumber(original)) < len(formatNumber(bound)) {
+		return formatNumber(original), true
+	}
+
+	return "", false
+}
+
+// formatOperand wraps the operand in parentheses when it binds looser than its parent
+func formatOperand(s Specification, parent int) string {
+	text, prec := formatSpecification(s)
+	if prec < parent {
+		return "(" + text + ")"
+	}
+
+	return text
+}
+
+// soleOperand looks through fluent wrappers and lists of one, which print as the operand they hold
+func soleOperand(s Specification) Specification {
+	for {
+		switch spec := s.(type) {
+		case FluentSpecification:
+			s = spec.Specification
+		case AllOfSpecification:
+			if len(spec.specs) != 1 {
+				return s
+			}
+			s = spec.specs[0]
+		case AnyOfSpecification:
+			if len(spec.specs) != 1 {
+				return s
+			}
+			s = spec.specs[0]
+		default:
+			return s
+		}
+	}
+}
+
+func formatList(specs []Specification, separator, empty string, prec int) (string, int) {
+	switch len(specs) {
+	case 0:
+		return empty, precedenceAtom
+	case 1:
+		return formatSpecification(specs[0])
+	}
+
+	parts := make([]string, len(specs))
+	for i, s := range specs {
+		parts[i] = formatOperand(s, prec)
+	}
+
+	return strings.Join(parts, separator), prec
+}
+
+func (c ColorSpecification) String() string         { return FormatSpecification(c) }
+func (s SizeSpecification) String() string          { return FormatSpecification(s) }
+func (ns NameSpecification) String() string         { return FormatSpecification(ns) }
+func (nm NameMatchSpecification) String() string    { return FormatSpecification(nm) }
+func (ns NotSpecification) String() string          { return FormatSpecification(ns) }
+func (as AndSpecification) String() string          { return FormatSpecification(as) }
+func (os OrSpecification) String() string           { return FormatSpecification(os) }
+func (as AllOfSpecification) String() string        { return FormatSpecification(as) }
+func (as AnyOfSpecification) String() string        { return FormatSpecification(as) }
+func (fs FluentSpecification) String() string       { return FormatSpecification(fs) }
+func (pr PriceRangeSpecification) String() string   { return FormatSpecification(pr) }
+func (wr WeightRangeSpecification) String() string  { return FormatSpecification(wr) }
+func (nc NameContainsSpecification) String() string { return FormatSpecification(nc) }
+func (nr NameRegexpSpecification) String() string   { return FormatSpecification(nr) }
+func (pt ProductTagSpecification) String() string   { return FormatSpecification(pt) }
@@ -0,0 +1,33 @@
+package solid
+
+import "testing"
+
+func TestFormatSpecificationLooksThroughListsOfOne(t *testing.T) {
+	weight := WeightRangeSpecification{0.5, 0.5}
+	tests := []struct {
+		spec Specification
+		want string
+	}{
+		{NotSpecification{AllOf(weight)}, "weight != 0.5"},
+		{NotSpecification{AnyOf(ColorSpecification{red})}, "color != red"},
+		{NotSpecification{AllOf(AnyOf(FluentSpecification{SizeSpecification{large}}))}, "size != large"},
+		{NotSpecification{AllOf(PriceRangeSpecification{1, 2})}, "NOT (price >= 1 AND price <= 2)"},
+	}
+
+	for _, tt := range tests {
+		got := FormatSpecification(tt.spec)
+		if got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+
+		// The text has to parse back to a specification printing the same way
+		parsed, err := ParseSpecification(got)
+		if err != nil {
+			t.Errorf("parsing %q: %v", got, err)
+			continue
+		}
+		if again := FormatSpecification(parsed); again != got {
+			t.Errorf("%q parses back to %q", got, again)
+		}
+	}
+}
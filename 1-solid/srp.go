@@ -1,34 +1,180 @@
 package solid
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // SRP: Single Responsibility Principle
 // This principle carries the idea that classes/structs should only have one responsibility
 
-var entryCount = 0
+var ErrEntryNotFound = errors.New("entry not found")
 
+// Each entry keeps its own ID, so removing one entry doesn't shift the others around
+type Entry struct {
+	ID       int       `json:"id"`
+	Text     string    `json:"text"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Tags     []string  `json:"tags,omitempty"`
+	Author   string    `json:"author,omitempty"`
+}
+
+// The metadata doesn't show up here, so the "N: text" rendering stays the same
+func (e Entry) String() string {
+	return fmt.Sprintf("%d: %s", e.ID, e.Text)
+}
+
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clone copies the tags too, so the copy doesn't share them with the original
+func (e Entry) clone() Entry {
+	if e.Tags != nil {
+		e.Tags = append([]string{}, e.Tags...)
+	}
+
+	return e
+}
+
+// Metadata is optional, so it goes through functional options instead of more AddEntry parameters
+type EntryOption func(*Entry)
+
+func WithTags(tags ...string) EntryOption {
+	return func(e *Entry) {
+		for _, t := range tags {
+			if !e.HasTag(t) {
+				e.Tags = append(e.Tags, t)
+			}
+		}
+	}
+}
+
+func WithAuthor(author string) EntryOption {
+	return func(e *Entry) {
+		e.Author = author
+	}
+}
+
+// Every journal numbers its own entries, and IDs are never reused after a removal
 type Journal struct {
-	entries []string
+	entries []Entry
+	lastID  int
 }
 
 func (j *Journal) String() string {
-	return strings.Join(j.entries, "\n")
+	lines := make([]string, len(j.entries))
+	for i, e := range j.entries {
+		lines[i] = e.String()
+	}
+
+	return strings.Join(lines, "\n")
 }
 
-func (j *Journal) AddEntry(text string) int {
-	entryCount++
-	entry := fmt.Sprintf("%d: %s", entryCount, text)
-	j.entries = append(j.entries, entry)
+// Entries returns a copy of the entries, so callers can't modify the journal behind its back
+func (j *Journal) Entries() []Entry {
+	result := make([]Entry, len(j.entries))
+	for i, e := range j.entries {
+		result[i] = e.clone()
+	}
 
-	return entryCount
+	return result
 }
 
-func (j *Journal) RemoveEntry(index int) {
-	// ...
+func (j *Journal) AddEntry(text string, options ...EntryOption) int {
+	e := j.newEntry(text, options)
+	j.entries = append(j.entries, e)
+	j.lastID = e.ID
+
+	return e.ID
+}
+
+// newEntry prepares the next entry without adding it yet
+func (j *Journal) newEntry(text string, options []EntryOption) Entry {
+	now := time.Now()
+	e := Entry{ID: j.lastID + 1, Text: text, Created: now, Modified: now}
+	for _, option := range options {
+		option(&e)
+	}
+
+	return e
+}
+
+func (j *Journal) indexOf(id int) (int, error) {
+	for i, e := range j.entries {
+		if e.ID == id {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("%w: %d", ErrEntryNotFound, id)
+}
+
+func (j *Journal) GetEntry(id int) (Entry, error) {
+	i, err := j.indexOf(id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return j.entries[i].clone(), nil
+}
+
+func (j *Journal) UpdateEntry(id int, text string) error {
+	i, err := j.indexOf(id)
+	if err != nil {
+		return err
+	}
+	j.entries[i].Text = text
+	j.entries[i].Modified = time.Now()
+
+	return nil
+}
+
+func (j *Journal) RemoveEntry(id int) error {
+	i, err := j.indexOf(id)
+	if err != nil {
+		return err
+	}
+	j.entries = append(j.entries[:i], j.entries[i+1:]...)
+
+	return nil
+}
+
+// restoreEntry puts back an entry that already has its ID, e.g. one read from a file
+func (j *Journal) restoreEntry(e Entry) error {
+	if e.ID <= 0 {
+		return fmt.Errorf("%w: invalid id %d", ErrMalformedEntry, e.ID)
+	}
+	// IDs only grow, so we only need to look for a duplicate when entries come back out of order
+	if e.ID <= j.lastID {
+		if _, err := j.indexOf(e.ID); err == nil {
+			return fmt.Errorf("%w: %d", ErrDuplicateEntryID, e.ID)
+		}
+	}
+
+	j.entries = append(j.entries, e.clone())
+	if e.ID > j.lastID {
+		j.lastID = e.ID
+	}
+
+	return nil
+}
+
+// clone gives stores their own copy of a journal
+func (j *Journal) clone() *Journal {
+	return &Journal{entries: j.Entries(), lastID: j.lastID}
 }
 
 // we need to apply the SEPARATION OF CONCERNS
@@ -52,9 +198,79 @@ type Persistence struct {
 	lineSeparator string
 }
 
-func (p *Persistence) SaveToFile(j *Journal, filename string) {
-	_ = ioutil.WriteFile(filename,
-		[]byte(strings.Join(j.entries, p.lineSeparator)), 0644)
+var ErrInvalidSeparator = errors.New("invalid line separator")
+
+// An empty separator would make every entry look like it contains the separator
+func NewPersistence(lineSeparator string) (*Persistence, error) {
+	if lineSeparator == "" {
+		return nil, fmt.Errorf("%w: it can't be empty", ErrInvalidSeparator)
+	}
+
+	return &Persistence{lineSeparator}, nil
+}
+
+// ErrUnsavableEntry is for entries that would be cut or merged with their neighbours in the file,
+// which could never be read back as the same entry
+var ErrUnsavableEntry = errors.New("entry can't be read back with this line separator")
+
+func (p *Persistence) SaveToFile(j *Journal, filename string) error {
+	lines := make([]string, len(j.entries))
+	for i, e := range j.entries {
+		lines[i] = e.String()
+	}
+	data := strings.Join(lines, p.lineSeparator)
+	if err := p.checkReadsBack(j, data); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, []byte(data), 0644)
+}
+
+// checkReadsBack parses what we're about to write, as the separator can show up anywhere in a line,
+// "N: " prefix included, or even across two lines
+func (p *Persistence) checkReadsBack(j *Journal, data string) error {
+	loaded, err := p.parse([]byte(data))
+	if err == nil && len(loaded.entries) == len(j.entries) {
+		same := true
+		for i, e := range j.entries {
+			same = same && loaded.entries[i].ID == e.ID && loaded.entries[i].Text == e.Text
+		}
+		if same {
+			return nil
+		}
+	}
+
+	// The first entry whose line contains the separator is the likely culprit, otherwise we can only blame the first one
+	for _, e := range j.entries {
+		if p.splits(e.String()) {
+			return fmt.Errorf("%w: entry %d", ErrUnsavableEntry, e.ID)
+		}
+	}
+
+	return fmt.Errorf("%w: entry %d", ErrUnsavableEntry, j.entries[0].ID)
+}
+
+// splits tells whether the line would be cut when reading the file back
+// Newline separators are read back as either "\n" or "\r\n", so any line break in the line counts
+func (p *Persistence) splits(line string) bool {
+	if p.lineSeparator == "\n" || p.lineSeparator == "\r\n" {
+		return strings.ContainsAny(line, "\r\n")
+	}
+
+	return strings.Contains(line, p.lineSeparator)
+}
+
+// LoadFromFile reads back what SaveToFile wrote, so saving the loaded journal gives the same file
+// The file only has the entries though: new IDs continue after the highest one in it,
+// so the IDs of entries removed after that one can be handed out again
+// The JSONFileStore and the JournalLog keep the last ID, use them when that matters
+func (p *Persistence) LoadFromFile(filename string) (*Journal, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parse(data)
 }
 
 func Srp() {
@@ -62,9 +278,91 @@ func Srp() {
 	j := Journal{}
 	j.AddEntry("I'm pissed today")
 	j.AddEntry("My belly hurts")
+	id := j.AddEntry("Nevermind")
+	if err := j.RemoveEntry(id); err != nil {
+		fmt.Println("Could not remove the entry:", err)
+	}
 	fmt.Println(j.String())
 
+	// Entries can carry tags and an author, and be queried with composable specifications
+	chores := Journal{}
+	chores.AddEntry("Fixed the leaking tap", WithTags("home", "chores"), WithAuthor("Pedro"))
+	chores.AddEntry("Paid the bills", WithTags("chores"))
+	tapChores := NewAndEntrySpecification(NewTagSpecification("chores"), NewTextSpecification("tap"))
+	for _, e := range chores.Query(tapChores) {
+		fmt.Printf("Found %q by %s tagged %v\n", e.Text, e.Author, e.Tags)
+	}
+
+	// Exporting is kept out of the Journal as well, each format has its own exporter
+	if err := (CSVExporter{}).Export(os.Stdout, &chores); err != nil {
+		fmt.Println("Could not export the journal:", err)
+	}
+
+	// When several goroutines share a journal, the ConcurrentJournal keeps it safe and tells subscribers what changed
+	shared := NewConcurrentJournal()
+	events, unsubscribe := shared.SubscribeChan()
+	go shared.AddEntry("Written from another goroutine")
+	e := <-events
+	fmt.Printf("Entry %d was %s: %s\n", e.Entry.ID, e.Kind, e.Entry.Text)
+	unsubscribe()
+
+	// Searching big journals goes through an inverted index instead of scanning every entry
+	index := IndexJournal(&chores)
+	if results, err := index.Search("bill*"); err == nil {
+		for _, r := range results {
+			found, _ := chores.GetEntry(r.ID)
+			fmt.Printf("Search found %q (score %.2f)\n", found.Text, r.Score)
+		}
+	}
+
 	// Handling persistence
 	p := Persistence{"\r\n"}
-	p.SaveToFile(&j, "journal.txt")
+	if err := p.SaveToFile(&j, "journal.txt"); err != nil {
+		fmt.Println("Could not save the journal:", err)
+	}
+
+	// And load it back, numbering included
+	if restored, err := p.LoadFromFile("journal.txt"); err != nil {
+		fmt.Println("Could not load the journal:", err)
+	} else {
+		fmt.Println("Restored", len(restored.Entries()), "entries from journal.txt")
+	}
+
+	// For big journals, an append-only log only writes what changed instead of the whole file
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		fmt.Println("Could not create a directory for the log:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	log, err := OpenJournalLog(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		fmt.Println("Could not open the journal log:", err)
+		return
+	}
+	for _, e := range j.Entries() {
+		_, _ = log.AddEntry(e.Text)
+	}
+	if err := log.Compact(); err != nil {
+		fmt.Println("Could not compact the journal log:", err)
+	}
+	fmt.Println("The log holds", len(log.Journal().Entries()), "entries")
+	log.Close()
+
+	// Going one step further, the persistence can sit behind an interface
+	// so whoever stores journals doesn't care whether they go to a file, JSON or memory
+	var store JournalStore = NewMemoryStore()
+	if err := store.Save("today", &j); err != nil {
+		fmt.Println("Could not store the journal:", err)
+		return
+	}
+
+	loaded, err := store.Load("today")
+	if err != nil {
+		fmt.Println("Could not load the journal:", err)
+		return
+	}
+	fmt.Println("Loaded back from the store:")
+	fmt.Println(loaded.String())
 }
@@ -0,0 +1,91 @@
+package solid
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistenceRoundTrip(t *testing.T) {
+	for _, separator := range []string{"\n", "\r\n", " | "} {
+		j := &Journal{}
+		j.AddEntry("first")
+		j.AddEntry("second: with a colon")
+
+		filename := filepath.Join(t.TempDir(), "journal.txt")
+		p, err := NewPersistence(separator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.SaveToFile(j, filename); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := p.LoadFromFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.String() != j.String() {
+			t.Errorf("separator %q: got %q back, want %q", separator, loaded.String(), j.String())
+		}
+	}
+}
+
+func TestPersistenceRejectsTextWithSeparator(t *testing.T) {
+	tests := []struct {
+		separator, text string
+	}{
+		{"\n", "line1\nline2"},
+		{"\n", "ends with a carriage return\r"},
+		{"\r\n", "line1\nline2"},
+		{" | ", "this | that"},
+		// The "N: " prefix has the separator in it too
+		{" ", "word"},
+		{":", "word"},
+		{": ", "word"},
+		// No line has the separator, but the end of one and the separator together do
+		{"aba", "ends with ab"},
+	}
+
+	for _, tt := range tests {
+		j := &Journal{}
+		j.AddEntry(tt.text)
+		j.AddEntry("second")
+		p, err := NewPersistence(tt.separator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.SaveToFile(j, filepath.Join(t.TempDir(), "journal.txt"))
+		if !errors.Is(err, ErrUnsavableEntry) {
+			t.Errorf("saving %q with separator %q: got %v, want %v", tt.text, tt.separator, err, ErrUnsavableEntry)
+		}
+	}
+}
+
+func TestPersistenceRejectsEmptySeparator(t *testing.T) {
+	if _, err := NewPersistence(""); !errors.Is(err, ErrInvalidSeparator) {
+		t.Errorf("got %v, want ErrInvalidSeparator", err)
+	}
+}
+
+func TestTextFileStoreRejectsEmptySeparator(t *testing.T) {
+	if _, err := NewTextFileStore(t.TempDir(), ""); !errors.Is(err, ErrInvalidSeparator) {
+		t.Errorf("got %v, want ErrInvalidSeparator", err)
+	}
+
+	store, err := NewTextFileStore(t.TempDir(), "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := &Journal{}
+	j.AddEntry("first")
+	if err := store.Save("daily", j); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load("daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.String() != j.String() {
+		t.Errorf("got %q back, want %q", loaded.String(), j.String())
+	}
+}
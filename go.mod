@@ -1,3 +1,5 @@
 module github.com/pedr0diniz
 
 go 1.18
+
+require golang.org/x/crypto v0.24.0